@@ -35,7 +35,12 @@ func Init() {
 	bootstrap2.Log()
 	bootstrap2.InitDB()
 	data.InitData()
+	bootstrap2.LoadAccounts()
+	bootstrap2.InitBandwidth()
+	bootstrap2.InitTaskManagers()
+	bootstrap2.InitSyncJobs()
 	bootstrap2.InitAria2()
+	bootstrap2.InitTus()
 }
 func main() {
 	Init()
@@ -0,0 +1,167 @@
+package alias
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	paths []string
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.paths = parsePaths(d.Paths)
+	if len(d.paths) == 0 {
+		return errors.New("paths is required")
+	}
+	self := utils.GetActualVirtualPath(utils.StandardizePath(d.VirtualPath))
+	for _, p := range d.paths {
+		// an alias containing itself will make List recursive infinitely
+		if utils.IsSubPath(p, self) || utils.IsSubPath(self, p) {
+			return errors.Errorf("path %s conflicts with the virtual path of the alias itself", p)
+		}
+	}
+	return nil
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	ctx, err := d.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	path := dir.GetID()
+	var files []model.Obj
+	index := make(map[string]int)
+	found := false
+	for _, root := range d.paths {
+		objs, err := list(ctx, stdpath.Join(root, path))
+		if err != nil {
+			// the objs of the path failed would look removed if it's skipped
+			if notFound(err) {
+				continue
+			}
+			return nil, errors.WithMessagef(err, "failed list %s", stdpath.Join(root, path))
+		}
+		found = true
+		for _, obj := range objs {
+			file := &model.Object{
+				ID:       stdpath.Join(path, obj.GetName()),
+				Name:     obj.GetName(),
+				Size:     obj.GetSize(),
+				Modified: obj.ModTime(),
+				IsFolder: obj.IsDir(),
+				Hash:     operations.HashOf(obj),
+			}
+			if i, ok := index[file.Name]; ok {
+				if d.prefer(file, files[i]) {
+					files[i] = file
+				}
+				continue
+			}
+			index[file.Name] = len(files)
+			files = append(files, file)
+		}
+	}
+	if !found {
+		return nil, errors.WithMessagef(errs.ObjectNotFound, "%s is not found in any path", path)
+	}
+	return files, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			ID:       "/",
+			Name:     "root",
+			Modified: d.Modified,
+			IsFolder: true,
+		}, nil
+	}
+	ctx, err := d.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := d.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Object{
+		ID:       path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+		Hash:     operations.HashOf(obj),
+	}, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	ctx, err := d.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, owner, err := d.get(ctx, file.GetID())
+	if err != nil {
+		return nil, err
+	}
+	link, _, err := fs.Link(ctx, owner, args)
+	return link, err
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	return errs.NotSupport
+}
+
+func (d *Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.Getter = (*Driver)(nil)
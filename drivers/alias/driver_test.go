@@ -0,0 +1,220 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+	// the streams put to Local are cached here to compute the hashes
+	conf.Conf.TempDir = os.TempDir()
+}
+
+func createLocal(t *testing.T, virtualPath string, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "Local",
+		VirtualPath: virtualPath,
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local account: %+v", err)
+	}
+}
+
+func TestDriver(t *testing.T) {
+	createLocal(t, "/a", map[string]string{"movies/x.txt": "a", "movies/sub/z.txt": "z"})
+	createLocal(t, "/b", map[string]string{"movies/x.txt": "bb", "movies/y.txt": "y"})
+	ctx := context.Background()
+
+	var tests = []struct {
+		priority string
+		size     int64
+		file     string
+	}{
+		{priority: "order", size: 1, file: "/a/movies/x.txt"},
+		{priority: "largest", size: 2, file: "/b/movies/x.txt"},
+	}
+	for _, test := range tests {
+		d := &Driver{}
+		err := d.Init(ctx, model.Account{
+			VirtualPath: "/merged",
+			Driver:      "Alias",
+			Addition:    fmt.Sprintf(`{"paths":"/a/movies\n/b/movies","priority":"%s"}`, test.priority),
+		})
+		if err != nil {
+			t.Fatalf("failed to init driver: %+v", err)
+		}
+		objs, err := operations.List(ctx, d, "/")
+		if err != nil {
+			t.Fatalf("failed to list: %+v", err)
+		}
+		if len(objs) != 3 {
+			t.Errorf("expected 3 objs, got %d", len(objs))
+		}
+		for _, obj := range objs {
+			if obj.GetName() == "x.txt" && obj.GetSize() != test.size {
+				t.Errorf("[%s] expected size %d, got %d", test.priority, test.size, obj.GetSize())
+			}
+		}
+		link, _, err := operations.Link(ctx, d, "/x.txt", model.LinkArgs{})
+		if err != nil {
+			t.Fatalf("failed to link: %+v", err)
+		}
+		if link.FilePath == nil {
+			t.Fatalf("expected file path of local")
+		}
+		if b, _ := os.ReadFile(*link.FilePath); int64(len(b)) != test.size {
+			t.Errorf("[%s] link to the wrong file: %s", test.priority, b)
+		}
+		sub, err := operations.List(ctx, d, "/sub")
+		if err != nil || len(sub) != 1 {
+			t.Errorf("failed to list sub dir: %+v", err)
+		}
+	}
+
+	d := &Driver{}
+	err := d.Init(ctx, model.Account{
+		VirtualPath: "/a/merged",
+		Driver:      "Alias",
+		Addition:    `{"paths":"/a\n/b"}`,
+	})
+	if err == nil {
+		t.Errorf("expected error while the alias contains itself")
+	}
+}
+
+func TestDriverErrors(t *testing.T) {
+	createLocal(t, "/c", nil)
+	ctx := context.Background()
+	// the hashes of the file put by Local are known
+	local, dir, err := operations.GetAccountAndActualPath("/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = operations.Put(ctx, local, dir, &model.FileStream{
+		Obj:        model.Object{Name: "x.txt", Size: 1, Modified: time.Now()},
+		ReadCloser: io.NopCloser(strings.NewReader("c")),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	for virtualPath, paths := range map[string]string{"/cycle1": "/cycle2", "/cycle2": "/cycle1"} {
+		err = operations.CreateAccount(ctx, model.Account{
+			VirtualPath: virtualPath,
+			Driver:      "Alias",
+			Addition:    fmt.Sprintf(`{"paths":"%s"}`, paths),
+		})
+		if err != nil {
+			t.Fatalf("failed to create alias: %+v", err)
+		}
+	}
+	d, err := operations.GetAccountByVirtualPath("/cycle1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := operations.List(ctx, d, "/"); err == nil {
+		t.Errorf("expected error while the aliases refer to each other")
+	}
+
+	d = &Driver{}
+	err = d.Init(ctx, model.Account{
+		VirtualPath: "/merged_c",
+		Driver:      "Alias",
+		Addition:    `{"paths":"/c\n/not_exists"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	if _, err := operations.Get(ctx, d, "/missing.txt"); !errs.IsObjectNotFound(err) {
+		t.Errorf("expected not found, got %+v", err)
+	}
+	obj, err := operations.Get(ctx, d, "/x.txt")
+	if err != nil {
+		t.Fatalf("failed to get: %+v", err)
+	}
+	if operations.HashOf(obj)[utils.MD5] == "" {
+		t.Errorf("expected the hashes of the local file")
+	}
+}
+
+func TestDriverMeta(t *testing.T) {
+	createLocal(t, "/m", map[string]string{"pub/a.txt": "a", "pub/hidden.txt": "h", "secret/s.txt": "s"})
+	ctx := context.Background()
+	for _, meta := range []model.Meta{
+		{Path: "/m/pub", Hide: "hidden"},
+		{Path: "/m/secret", Password: "pwd"},
+	} {
+		meta := meta
+		if err := db.CreateMeta(&meta); err != nil {
+			t.Fatalf("failed to create meta: %+v", err)
+		}
+	}
+	d := &Driver{}
+	err := d.Init(ctx, model.Account{
+		VirtualPath: "/merged_m",
+		Driver:      "Alias",
+		Addition:    `{"paths":"/m/pub\n/m/secret"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	// the password of the backing path can't be given through the alias
+	if _, err := operations.List(ctx, d, "/"); !errors.Is(errors.Cause(err), errs.PermissionDenied) {
+		t.Errorf("expected permission denied, got %+v", err)
+	}
+
+	d = &Driver{}
+	err = d.Init(ctx, model.Account{
+		VirtualPath: "/merged_pub",
+		Driver:      "Alias",
+		Addition:    `{"paths":"/m/pub"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	objs, err := operations.List(ctx, d, "/")
+	if err != nil || len(objs) != 1 || objs[0].GetName() != "a.txt" {
+		t.Errorf("expected the hidden file filtered, got %v %+v", objs, err)
+	}
+	if _, err := operations.Get(ctx, d, "/hidden.txt"); !errs.IsObjectNotFound(err) {
+		t.Errorf("expected the hidden file not found, got %+v", err)
+	}
+	// the admin sees the hidden files
+	adminCtx := context.WithValue(ctx, "user", &model.User{Role: model.ADMIN})
+	if _, err := d.Get(adminCtx, "/hidden.txt"); err != nil {
+		t.Errorf("expected the hidden file got by admin, got %+v", err)
+	}
+}
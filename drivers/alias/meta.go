@@ -0,0 +1,26 @@
+package alias
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	Paths    string `json:"paths" required:"true" type:"text" help:"virtual paths to merge, one per line"`
+	Priority string `json:"priority" type:"select" values:"order,newest,largest" default:"order" help:"which one to use if names conflict, order means the former path wins"`
+}
+
+var config = driver.Config{
+	Name:      "Alias",
+	LocalSort: true,
+	NoCache:   true,
+	NoUpload:  true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
@@ -0,0 +1,149 @@
+package alias
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// prefer check if obj should replace the current one with the same name
+func (d *Driver) prefer(obj, current model.Obj) bool {
+	switch d.Priority {
+	case "newest":
+		return obj.ModTime().After(current.ModTime())
+	case "largest":
+		return obj.GetSize() > current.GetSize()
+	default:
+		return false
+	}
+}
+
+// get find the object in all paths, return it and the virtual path which owns it.
+// it's not found only if all paths report not found, the other errors are returned,
+// since the obj of the path failed may be the one preferred
+func (d *Driver) get(ctx context.Context, path string) (model.Obj, string, error) {
+	var res model.Obj
+	var owner string
+	for _, root := range d.paths {
+		p := stdpath.Join(root, path)
+		if _, err := guard(ctx, p); err != nil {
+			return nil, "", err
+		}
+		obj, err := fs.Get(ctx, p)
+		if err != nil {
+			if notFound(err) {
+				continue
+			}
+			return nil, "", errors.WithMessagef(err, "failed get %s", p)
+		}
+		// the hidden obj is not found through the alias, as it's not listed
+		dirMeta, err := guard(ctx, stdpath.Dir(p))
+		if err != nil {
+			return nil, "", err
+		}
+		if len(fs.Hide(userOf(ctx), dirMeta, stdpath.Dir(p), []model.Obj{obj})) == 0 {
+			continue
+		}
+		if res == nil || d.prefer(obj, res) {
+			res, owner = obj, p
+		}
+	}
+	if res == nil {
+		return nil, "", errors.WithMessagef(errs.ObjectNotFound, "%s is not found in any path", path)
+	}
+	return res, owner, nil
+}
+
+// list the objs of a virtual path, fs.List is not used because it
+// requires the meta and user in ctx, which are not always there, e.g. in a task.
+// the objs hidden by the meta of the path are filtered
+func list(ctx context.Context, path string) ([]model.Obj, error) {
+	meta, err := guard(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	virtualFiles := operations.GetAccountVirtualFilesByPath(path)
+	account, actualPath, err := operations.GetAccountAndActualPath(path)
+	if err != nil {
+		if len(virtualFiles) != 0 {
+			return virtualFiles, nil
+		}
+		return nil, err
+	}
+	objs, err := operations.List(ctx, account, actualPath)
+	if err != nil {
+		if len(virtualFiles) != 0 {
+			return fs.Hide(userOf(ctx), meta, path, virtualFiles), nil
+		}
+		return nil, err
+	}
+	return fs.Hide(userOf(ctx), meta, path, append(objs, virtualFiles...)), nil
+}
+
+// userOf return the user in ctx, the one without it, e.g. a task, is treated as a guest
+func userOf(ctx context.Context) *model.User {
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		return user
+	}
+	return &model.User{Role: model.GUEST}
+}
+
+// guard apply the nearest meta of the backing path to the user, since only the meta of
+// the alias path is checked before. the path protected by password is refused, because
+// the password can't be passed through the alias. the meta is returned to hide the objs
+func guard(ctx context.Context, path string) (*model.Meta, error) {
+	meta, err := db.GetNearestMeta(path)
+	if err != nil {
+		if errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return nil, nil
+		}
+		return nil, errors.WithMessagef(err, "failed get meta of %s", path)
+	}
+	if meta.Password == "" || userOf(ctx).CanAccessWithoutPassword() {
+		return meta, nil
+	}
+	if utils.PathEqual(meta.Path, path) || meta.PSub {
+		return nil, errors.Wrapf(errs.PermissionDenied, "%s is protected by password", path)
+	}
+	return meta, nil
+}
+
+// notFound check whether err means the path doesn't exist, the path
+// without any account to serve it doesn't exist too
+func notFound(err error) bool {
+	return errs.IsObjectNotFound(err) || errors.Is(err, errs.AccountNotFound)
+}
+
+type visitedKey struct{}
+
+// enter record the alias in ctx, so that the alias referring to itself through
+// other aliases, e.g. A -> B -> A, fails instead of recursing infinitely
+func (d *Driver) enter(ctx context.Context) (context.Context, error) {
+	visited, _ := ctx.Value(visitedKey{}).([]string)
+	if utils.SliceContains(visited, d.VirtualPath) {
+		return nil, errors.Errorf("alias %s refers to itself: %s -> %s",
+			d.VirtualPath, strings.Join(visited, " -> "), d.VirtualPath)
+	}
+	visited = append(visited[:len(visited):len(visited)], d.VirtualPath)
+	return context.WithValue(ctx, visitedKey{}, visited), nil
+}
+
+func parsePaths(paths string) []string {
+	var res []string
+	for _, p := range strings.Split(paths, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		res = append(res, utils.StandardizePath(p))
+	}
+	return res
+}
@@ -0,0 +1,381 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rfjakob/eme"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The format is compatible with rclone crypt,
+// see https://rclone.org/crypt/#file-formats
+const (
+	nameCipherBlockSize = aes.BlockSize
+	fileMagic           = "RCLONE\x00\x00"
+	fileMagicSize       = len(fileMagic)
+	fileNonceSize       = 24
+	fileHeaderSize      = fileMagicSize + fileNonceSize
+	blockHeaderSize     = secretbox.Overhead
+	blockDataSize       = 64 * 1024
+	blockSize           = blockHeaderSize + blockDataSize
+	encryptedSuffix     = ".bin"
+	// the data key, the name key and the name tweak
+	keySize = 32 + 32 + nameCipherBlockSize
+)
+
+// defaultSalt is used by rclone if the password2 is empty
+var defaultSalt = []byte{0xA8, 0x0D, 0xF4, 0x3A, 0x8F, 0xBD, 0x03, 0x08, 0xA7, 0xCA, 0xB8, 0x3E, 0x58, 0x1F, 0x86, 0xB1}
+
+var (
+	errBadMagic      = errors.New("not an encrypted file - bad magic string")
+	errBadDecrypt    = errors.New("failed to authenticate decrypted block - bad password?")
+	errBadSize       = errors.New("encrypted file has a bad size")
+	errBadName       = errors.New("not an encrypted name")
+	errBadPadding    = errors.New("bad padding of the decrypted name")
+	errShortFileName = errors.New("the name is too short to be an encrypted name")
+	nameEncoding     = base32.HexEncoding.WithPadding(base32.NoPadding)
+)
+
+type Cipher struct {
+	dataKey   [32]byte
+	nameKey   [32]byte
+	nameTweak [nameCipherBlockSize]byte
+	block     cipher.Block
+	// encrypt file names or just add the .bin suffix
+	nameEncryption    bool
+	dirNameEncryption bool
+}
+
+func newCipher(password, salt string, nameEncryption, dirNameEncryption bool) (*Cipher, error) {
+	if password == "" {
+		return nil, errors.New("password is required")
+	}
+	saltBytes := defaultSalt
+	if salt != "" {
+		saltBytes = []byte(salt)
+	}
+	key, err := scrypt.Key([]byte(password), saltBytes, 16384, 8, 1, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while derive key")
+	}
+	return newCipherWithKey(key, nameEncryption, dirNameEncryption)
+}
+
+func newCipherWithKey(key []byte, nameEncryption, dirNameEncryption bool) (*Cipher, error) {
+	c := &Cipher{
+		nameEncryption:    nameEncryption,
+		dirNameEncryption: nameEncryption && dirNameEncryption,
+	}
+	copy(c.dataKey[:], key)
+	copy(c.nameKey[:], key[len(c.dataKey):])
+	copy(c.nameTweak[:], key[len(c.dataKey)+len(c.nameKey):])
+	block, err := aes.NewCipher(c.nameKey[:])
+	if err != nil {
+		return nil, err
+	}
+	c.block = block
+	return c, nil
+}
+
+func (c *Cipher) encryptSegment(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	padded := pkcs7Pad([]byte(plaintext))
+	ciphertext := eme.Transform(c.block, c.nameTweak[:], padded, eme.DirectionEncrypt)
+	return strings.ToLower(nameEncoding.EncodeToString(ciphertext))
+}
+
+func (c *Cipher) decryptSegment(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	raw, err := nameEncoding.DecodeString(strings.ToUpper(ciphertext))
+	if err != nil {
+		return "", errBadName
+	}
+	if len(raw) == 0 || len(raw)%nameCipherBlockSize != 0 {
+		return "", errShortFileName
+	}
+	padded := eme.Transform(c.block, c.nameTweak[:], raw, eme.DirectionDecrypt)
+	plaintext, err := pkcs7Unpad(padded)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (c *Cipher) encryptFileName(name string) string {
+	if !c.nameEncryption {
+		return name + encryptedSuffix
+	}
+	return c.encryptSegment(name)
+}
+
+func (c *Cipher) decryptFileName(name string) (string, error) {
+	if !c.nameEncryption {
+		if !strings.HasSuffix(name, encryptedSuffix) || name == encryptedSuffix {
+			return "", errBadName
+		}
+		return strings.TrimSuffix(name, encryptedSuffix), nil
+	}
+	return c.decryptSegment(name)
+}
+
+func (c *Cipher) encryptDirName(name string) string {
+	if !c.dirNameEncryption {
+		return name
+	}
+	return c.encryptSegment(name)
+}
+
+func (c *Cipher) decryptDirName(name string) (string, error) {
+	if !c.dirNameEncryption {
+		return name, nil
+	}
+	return c.decryptSegment(name)
+}
+
+// encryptDirPath encrypt every segment of the path as dir name
+func (c *Cipher) encryptDirPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = c.encryptDirName(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// encryptedSize calculate the size of the encrypted file with the size of plaintext
+func encryptedSize(size int64) int64 {
+	blocks, residue := size/blockDataSize, size%blockDataSize
+	encryptedSize := int64(fileHeaderSize) + blocks*blockSize
+	if residue != 0 {
+		encryptedSize += blockHeaderSize + residue
+	}
+	return encryptedSize
+}
+
+// decryptedSize calculate the size of the plaintext with the size of encrypted file
+func decryptedSize(size int64) (int64, error) {
+	size -= int64(fileHeaderSize)
+	if size < 0 {
+		return 0, errBadSize
+	}
+	blocks, residue := size/blockSize, size%blockSize
+	decryptedSize := blocks * blockDataSize
+	if residue != 0 {
+		residue -= blockHeaderSize
+		if residue <= 0 {
+			return 0, errBadSize
+		}
+		decryptedSize += residue
+	}
+	return decryptedSize, nil
+}
+
+func pkcs7Pad(buf []byte) []byte {
+	n := nameCipherBlockSize - len(buf)%nameCipherBlockSize
+	return append(buf, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(buf []byte) ([]byte, error) {
+	if len(buf) == 0 || len(buf)%nameCipherBlockSize != 0 {
+		return nil, errBadPadding
+	}
+	n := int(buf[len(buf)-1])
+	if n == 0 || n > nameCipherBlockSize {
+		return nil, errBadPadding
+	}
+	for _, b := range buf[len(buf)-n:] {
+		if int(b) != n {
+			return nil, errBadPadding
+		}
+	}
+	return buf[:len(buf)-n], nil
+}
+
+// nonce is a little endian number used by secretbox, increased by one for each block
+type nonce [fileNonceSize]byte
+
+func (n *nonce) carry(i int) {
+	for ; i < len(*n); i++ {
+		digit := (*n)[i]
+		newDigit := digit + 1
+		(*n)[i] = newDigit
+		if newDigit >= digit {
+			// no carry
+			break
+		}
+	}
+}
+
+func (n *nonce) increment() {
+	n.carry(0)
+}
+
+func (n *nonce) add(x uint64) {
+	carry := uint16(0)
+	for i := 0; i < 8; i++ {
+		digit := (*n)[i]
+		xDigit := byte(x)
+		x >>= 8
+		carry += uint16(digit) + uint16(xDigit)
+		(*n)[i] = byte(carry)
+		carry >>= 8
+	}
+	if carry != 0 {
+		n.carry(8)
+	}
+}
+
+// encrypter encrypt the plaintext read from in to the rclone crypt format
+type encrypter struct {
+	in    io.Reader
+	key   *[32]byte
+	nonce nonce
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+func (c *Cipher) newEncrypter(in io.Reader) (*encrypter, error) {
+	e := &encrypter{
+		in:  in,
+		key: &c.dataKey,
+		buf: make([]byte, blockDataSize),
+		out: make([]byte, 0, blockSize),
+	}
+	if _, err := io.ReadFull(rand.Reader, e.nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "error while generate nonce")
+	}
+	e.out = append(e.out, fileMagic...)
+	e.out = append(e.out, e.nonce[:]...)
+	return e, nil
+}
+
+func (e *encrypter) Read(p []byte) (int, error) {
+	if len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		n, err := io.ReadFull(e.in, e.buf)
+		if n > 0 {
+			e.out = secretbox.Seal(e.out[:0], e.buf[:n], (*[fileNonceSize]byte)(&e.nonce), e.key)
+			e.nonce.increment()
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			e.err = io.EOF
+		} else if err != nil {
+			e.err = err
+		}
+		if n == 0 {
+			return 0, e.err
+		}
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// decrypter decrypt the blocks read from rc, which must start at a block boundary,
+// the first discard bytes of plaintext are dropped and at most limit bytes are returned
+type decrypter struct {
+	rc      io.ReadCloser
+	key     *[32]byte
+	nonce   nonce
+	buf     []byte
+	plain   []byte
+	out     []byte
+	discard int64
+	limit   int64
+	err     error
+}
+
+func (c *Cipher) newDecrypter(rc io.ReadCloser, n nonce, discard, limit int64) *decrypter {
+	return &decrypter{
+		rc:      rc,
+		key:     &c.dataKey,
+		nonce:   n,
+		buf:     make([]byte, blockSize),
+		plain:   make([]byte, 0, blockDataSize),
+		discard: discard,
+		limit:   limit,
+	}
+}
+
+func (d *decrypter) fill() error {
+	n, err := io.ReadFull(d.rc, d.buf)
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if n <= blockHeaderSize {
+		return errBadSize
+	}
+	out, ok := secretbox.Open(d.plain[:0], d.buf[:n], (*[fileNonceSize]byte)(&d.nonce), d.key)
+	if !ok {
+		return errBadDecrypt
+	}
+	d.nonce.increment()
+	if d.discard > 0 {
+		if d.discard >= int64(len(out)) {
+			d.discard -= int64(len(out))
+			out = out[:0]
+		} else {
+			out = out[d.discard:]
+			d.discard = 0
+		}
+	}
+	d.out = out
+	return nil
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	if d.limit <= 0 {
+		return 0, io.EOF
+	}
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.fill()
+	}
+	if int64(len(p)) > d.limit {
+		p = p[:d.limit]
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	d.limit -= int64(n)
+	return n, nil
+}
+
+func (d *decrypter) Close() error {
+	return d.rc.Close()
+}
+
+// readHeader read the file header and return the nonce of the first block
+func readHeader(r io.Reader) (nonce, error) {
+	var n nonce
+	header := make([]byte, fileHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, errBadSize
+		}
+		return n, err
+	}
+	if string(header[:fileMagicSize]) != fileMagic {
+		return n, errBadMagic
+	}
+	copy(n[:], header[fileMagicSize:])
+	return n, nil
+}
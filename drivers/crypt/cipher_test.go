@@ -0,0 +1,92 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// the expected values are from the tests of rclone crypt, with an all zero key
+func TestEncryptSegment(t *testing.T) {
+	c, err := newCipherWithKey(make([]byte, keySize), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"1", "p0e52nreeaj0a5ea7s64m4j72s"},
+		{"12", "l42g6771hnv3an9cgc8cr2n1ng"},
+		{"123", "qgm4avr35m5loi1th53ato71v0"},
+	}
+	for _, test := range tests {
+		actual := c.encryptSegment(test.in)
+		if actual != test.expected {
+			t.Errorf("encrypt %q: expected %q, got %q", test.in, test.expected, actual)
+		}
+		recovered, err := c.decryptSegment(actual)
+		if err != nil || recovered != test.in {
+			t.Errorf("decrypt %q: expected %q, got %q, %+v", actual, test.in, recovered, err)
+		}
+	}
+	if _, err := c.decryptSegment("not-encrypted"); err == nil {
+		t.Errorf("expected error while decrypt a plain name")
+	}
+}
+
+func TestSize(t *testing.T) {
+	for _, size := range []int64{0, 1, blockDataSize - 1, blockDataSize, blockDataSize + 1, 10*blockDataSize + 7} {
+		decrypted, err := decryptedSize(encryptedSize(size))
+		if err != nil || decrypted != size {
+			t.Errorf("size %d: got %d, %+v", size, decrypted, err)
+		}
+	}
+	if _, err := decryptedSize(int64(fileHeaderSize + blockHeaderSize)); err == nil {
+		t.Errorf("expected error of bad size")
+	}
+}
+
+func TestEmptyPassword(t *testing.T) {
+	if _, err := newCipher("", "salt", true, true); err == nil {
+		t.Errorf("expected error of empty password")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := newCipher("password", "salt", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 3*blockDataSize+100)
+	_, _ = rand.Read(plaintext)
+	e, err := c.newEncrypter(bytes.NewReader(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := io.ReadAll(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(ciphertext)) != encryptedSize(int64(len(plaintext))) {
+		t.Fatalf("unexpected encrypted size %d", len(ciphertext))
+	}
+	n, err := readHeader(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// decrypt from the middle of the second block
+	start, length := int64(blockDataSize+10), int64(blockDataSize*2)
+	n.add(1)
+	rc := ioutil.NopCloser(bytes.NewReader(ciphertext[fileHeaderSize+blockSize:]))
+	data, err := io.ReadAll(c.newDecrypter(rc, n, 10, length))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, plaintext[start:start+length]) {
+		t.Errorf("decrypted data mismatch")
+	}
+}
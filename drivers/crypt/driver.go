@@ -0,0 +1,248 @@
+package crypt
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	cipher *Cipher
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.RemotePath = utils.StandardizePath(d.RemotePath)
+	self := utils.GetActualVirtualPath(utils.StandardizePath(d.VirtualPath))
+	// the crypt containing itself will make List recursive infinitely
+	if utils.IsSubPath(d.RemotePath, self) || utils.IsSubPath(self, d.RemotePath) {
+		return errors.Errorf("remote path %s conflicts with the virtual path of the crypt itself", d.RemotePath)
+	}
+	d.cipher, err = newCipher(d.Password, d.Salt, d.FilenameEncryption != "off", d.DirectoryNameEncryption)
+	return err
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	remoteDir := d.getRemoteDir(dir.GetID())
+	objs, err := listRemote(ctx, remoteDir)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list remote dir %s", remoteDir)
+	}
+	files := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		// skip the objs which are not encrypted by the crypt
+		file, err := d.decryptObj(obj)
+		if err != nil {
+			continue
+		}
+		file.ID = stdpath.Join(dir.GetID(), file.Name)
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			ID:       "/",
+			Name:     "root",
+			Modified: d.Modified,
+			IsFolder: true,
+		}, nil
+	}
+	// the name of a file and a dir may be encrypted differently, try both
+	for _, isDir := range []bool{false, true} {
+		obj, err := getRemote(ctx, d.getRemotePath(path, isDir))
+		if err != nil || obj.IsDir() != isDir {
+			continue
+		}
+		file, err := d.decryptObj(obj)
+		if err != nil {
+			continue
+		}
+		file.ID = path
+		return file, nil
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
+func (d *Driver) decryptObj(obj model.Obj) (*model.Object, error) {
+	file := &model.Object{
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+	}
+	var err error
+	if obj.IsDir() {
+		file.Name, err = d.cipher.decryptDirName(obj.GetName())
+		return file, err
+	}
+	file.Name, err = d.cipher.decryptFileName(obj.GetName())
+	if err != nil {
+		return nil, err
+	}
+	file.Size, err = decryptedSize(obj.GetSize())
+	return file, err
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	remotePath := d.getRemotePath(file.GetID(), false)
+	size := file.GetSize()
+	var (
+		mu        sync.Mutex
+		fileNonce *nonce
+	)
+	// the header is read by the first range and reused by the later ones
+	readNonce := func(rc io.Reader) (nonce, error) {
+		n, err := readHeader(rc)
+		if err != nil {
+			return n, errors.WithMessagef(err, "failed read header of %s", remotePath)
+		}
+		mu.Lock()
+		fileNonce = &n
+		mu.Unlock()
+		return n, nil
+	}
+	rangeReader := func(ctx context.Context, start, length int64) (io.ReadCloser, error) {
+		if length < 0 || start+length > size {
+			length = size - start
+		}
+		if length <= 0 {
+			return ioutil.NopCloser(io.LimitReader(nil, 0)), nil
+		}
+		// only read the blocks containing the range
+		firstBlock, lastBlock := start/blockDataSize, (start+length-1)/blockDataSize
+		offset := int64(fileHeaderSize) + firstBlock*blockSize
+		end := int64(fileHeaderSize) + (lastBlock+1)*blockSize
+		if encrypted := encryptedSize(size); end > encrypted {
+			end = encrypted
+		}
+		mu.Lock()
+		cached := fileNonce
+		mu.Unlock()
+		var n nonce
+		if cached != nil {
+			n = *cached
+		} else if firstBlock == 0 {
+			// the header is right before the first block, read them together
+			offset = 0
+		} else {
+			header, err := fs.OpenRange(ctx, remotePath, 0, int64(fileHeaderSize))
+			if err != nil {
+				return nil, err
+			}
+			n, err = readNonce(header)
+			_ = header.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		rc, err := fs.OpenRange(ctx, remotePath, offset, end-offset)
+		if err != nil {
+			return nil, err
+		}
+		if offset == 0 {
+			if n, err = readNonce(rc); err != nil {
+				_ = rc.Close()
+				return nil, err
+			}
+		}
+		n.add(uint64(firstBlock))
+		return d.cipher.newDecrypter(rc, n, start-firstBlock*blockDataSize, length), nil
+	}
+	return &model.Link{RangeReader: model.RangeReaderFunc(rangeReader)}, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	remoteDir := d.getRemoteDir(stdpath.Join(parentDir.GetID(), dirName))
+	err := fs.MakeDir(ctx, remoteDir)
+	fs.ClearCache(stdpath.Dir(remoteDir))
+	return err
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := d.getRemotePath(srcObj.GetID(), srcObj.IsDir())
+	dstPath := d.getRemoteDir(dstDir.GetID())
+	err := fs.Move(ctx, srcPath, dstPath)
+	fs.ClearCache(stdpath.Dir(srcPath))
+	fs.ClearCache(dstPath)
+	return err
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	srcPath := d.getRemotePath(srcObj.GetID(), srcObj.IsDir())
+	dstPath := d.getRemotePath(stdpath.Join(stdpath.Dir(srcObj.GetID()), newName), srcObj.IsDir())
+	err := fs.Rename(ctx, srcPath, stdpath.Base(dstPath))
+	fs.ClearCache(stdpath.Dir(srcPath))
+	return err
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := d.getRemotePath(srcObj.GetID(), srcObj.IsDir())
+	dstPath := d.getRemoteDir(dstDir.GetID())
+	_, err := fs.Copy(ctx, srcPath, dstPath)
+	fs.ClearCache(dstPath)
+	return err
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	remotePath := d.getRemotePath(obj.GetID(), obj.IsDir())
+	err := fs.Remove(ctx, remotePath)
+	fs.ClearCache(stdpath.Dir(remotePath))
+	return err
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	account, actualPath, err := operations.GetAccountAndActualPath(d.getRemoteDir(dstDir.GetID()))
+	if err != nil {
+		return errors.WithMessage(err, "failed get remote account")
+	}
+	encrypter, err := d.cipher.newEncrypter(stream)
+	if err != nil {
+		return err
+	}
+	// the stream is closed by the caller, so don't close it in the remote account
+	return operations.Put(ctx, account, actualPath, &model.FileStream{
+		Obj: model.Object{
+			Name:     d.cipher.encryptFileName(stream.GetName()),
+			Size:     encryptedSize(stream.GetSize()),
+			Modified: stream.ModTime(),
+		},
+		ReadCloser: ioutil.NopCloser(encrypter),
+		Mimetype:   "application/octet-stream",
+	}, up)
+}
+
+func (d *Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.Getter = (*Driver)(nil)
@@ -0,0 +1,147 @@
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+	// the streams put to Local are cached here to compute the hashes
+	conf.Conf.TempDir = os.TempDir()
+}
+
+func TestDriver(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	err := operations.CreateAccount(ctx, model.Account{
+		Driver:      "Local",
+		VirtualPath: "/local",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local account: %+v", err)
+	}
+	d := &Driver{}
+	err = d.Init(ctx, model.Account{
+		VirtualPath: "/crypt",
+		Driver:      "Crypt",
+		Addition:    `{"remote_path":"/local/encrypted","password":"password","filename_encryption":"standard","directory_name_encryption":true}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+
+	content := make([]byte, 2*blockDataSize+123)
+	_, _ = rand.Read(content)
+	err = operations.Put(ctx, d, "/dir", &model.FileStream{
+		Obj:        model.Object{Name: "secret.txt", Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	encryptedDir := filepath.Join(root, "encrypted", d.cipher.encryptDirName("dir"))
+	raw, err := os.ReadFile(filepath.Join(encryptedDir, d.cipher.encryptFileName("secret.txt")))
+	if err != nil {
+		t.Fatalf("expected encrypted file in local: %+v", err)
+	}
+	if !bytes.HasPrefix(raw, []byte(fileMagic)) || int64(len(raw)) != encryptedSize(int64(len(content))) {
+		t.Errorf("unexpected encrypted file")
+	}
+
+	objs, err := operations.List(ctx, d, "/dir")
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "secret.txt" || objs[0].GetSize() != int64(len(content)) {
+		t.Fatalf("unexpected list result: %+v", objs)
+	}
+
+	var tests = []struct {
+		length int64
+		start  int64
+		end    int64
+	}{
+		{length: -1, start: 0, end: int64(len(content))},
+		{length: 16, start: 65530, end: 65546},
+		{length: 10, start: int64(len(content)) - 10, end: int64(len(content))},
+	}
+	link, err := d.Link(ctx, objs[0], model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link: %+v", err)
+	}
+	for _, test := range tests {
+		rc, err := link.RangeReader.RangeRead(ctx, test.start, test.length)
+		if err != nil {
+			t.Fatalf("failed to read range: %+v", err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read: %+v", err)
+		}
+		if !bytes.Equal(data, content[test.start:test.end]) {
+			t.Errorf("data mismatch of range [%d, %d)", test.start, test.end)
+		}
+	}
+
+	// a new link read from the middle first has to fetch the header alone
+	link, err = d.Link(ctx, objs[0], model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link: %+v", err)
+	}
+	for _, test := range []struct{ start, end int64 }{{65540, 65550}, {0, 10}} {
+		rc, err := link.RangeReader.RangeRead(ctx, test.start, test.end-test.start)
+		if err != nil {
+			t.Fatalf("failed to read range: %+v", err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil || !bytes.Equal(data, content[test.start:test.end]) {
+			t.Errorf("data mismatch of range [%d, %d): %+v", test.start, test.end, err)
+		}
+	}
+
+	file, err := operations.Get(ctx, d, "/dir/secret.txt")
+	if err != nil {
+		t.Fatalf("failed to get: %+v", err)
+	}
+	if err := d.Rename(ctx, file, "renamed.txt"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	if _, err := operations.Get(ctx, d, "/dir/renamed.txt"); err != nil {
+		t.Errorf("failed to get renamed file: %+v", err)
+	}
+	dir, err := operations.Get(ctx, d, "/dir")
+	if err != nil || !dir.IsDir() {
+		t.Fatalf("failed to get dir: %+v", err)
+	}
+	if err := d.Remove(ctx, dir); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(encryptedDir); !os.IsNotExist(err) {
+		t.Errorf("expected dir removed, got %+v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package crypt
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	RemotePath              string `json:"remote_path" required:"true" help:"the virtual path to store the encrypted files"`
+	Password                string `json:"password" required:"true" help:"the password of rclone crypt, not obscured"`
+	Salt                    string `json:"salt" help:"the password2 of rclone crypt, not obscured, optional"`
+	FilenameEncryption      string `json:"filename_encryption" type:"select" values:"standard,off" default:"standard"`
+	DirectoryNameEncryption bool   `json:"directory_name_encryption" default:"true"`
+}
+
+var config = driver.Config{
+	Name:      "Crypt",
+	LocalSort: true,
+	OnlyProxy: true,
+	NoCache:   true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
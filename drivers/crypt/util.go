@@ -0,0 +1,39 @@
+package crypt
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+// getRemoteDir convert the path of a dir to the encrypted virtual path
+func (d *Driver) getRemoteDir(path string) string {
+	return stdpath.Join(d.RemotePath, d.cipher.encryptDirPath(path))
+}
+
+// getRemotePath convert the path of an obj to the encrypted virtual path
+func (d *Driver) getRemotePath(path string, isDir bool) string {
+	if isDir {
+		return d.getRemoteDir(path)
+	}
+	return stdpath.Join(d.getRemoteDir(stdpath.Dir(path)), d.cipher.encryptFileName(stdpath.Base(path)))
+}
+
+// getRemote get the obj in remote path, operations is used rather than fs, so that not found is not logged as error
+func getRemote(ctx context.Context, path string) (model.Obj, error) {
+	account, actualPath, err := operations.GetAccountAndActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return operations.Get(ctx, account, actualPath)
+}
+
+func listRemote(ctx context.Context, path string) ([]model.Obj, error) {
+	account, actualPath, err := operations.GetAccountAndActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return operations.List(ctx, account, actualPath)
+}
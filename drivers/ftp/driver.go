@@ -0,0 +1,160 @@
+package ftp
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/errors"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	// conn is shared by commands which don't transfer files
+	conn *ftp.ServerConn
+	// mu protects conn, it's a pointer so that the Driver can be copied by the value receivers
+	mu *sync.Mutex
+	// disableMLSD is set to 1 once the server fails MLSD, it's accessed atomically
+	// since the connections for transfers are dialed without the lock
+	disableMLSD int32
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.RootFolder = utils.StandardizePath(d.RootFolder)
+	d.mu = &sync.Mutex{}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.conn, err = d.login(ctx)
+	return err
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	if d.mu == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		_ = d.conn.Quit()
+		d.conn = nil
+	}
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	entries, err := d.list(ctx, dir.GetID())
+	if err != nil {
+		return nil, err
+	}
+	files := make([]model.Obj, 0, len(entries))
+	for _, e := range entries {
+		files = append(files, entryToObj(e, stdpath.Join(dir.GetID(), e.Name)))
+	}
+	return files, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return &model.Link{RangeReader: d.rangeReader(file.GetID())}, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	fullPath := stdpath.Join(parentDir.GetID(), dirName)
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		return conn.MakeDir(fullPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while make dir %s", fullPath)
+	}
+	return nil
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		return conn.Rename(srcPath, dstPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while move %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(stdpath.Dir(srcPath), newName)
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		return conn.Rename(srcPath, dstPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while rename %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	var err error
+	if srcObj.IsDir() {
+		err = d.copyDir(ctx, srcPath, dstPath)
+	} else {
+		err = d.copyFile(ctx, srcPath, dstPath)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "error while copy %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if obj.IsDir() {
+			return conn.RemoveDirRecur(obj.GetID())
+		}
+		return conn.Delete(obj.GetID())
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while remove %s", obj.GetID())
+	}
+	return nil
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	fullPath := stdpath.Join(dstDir.GetID(), stream.GetName())
+	conn, err := d.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+	err = conn.Stor(fullPath, driver.NewProgressReader(&ctxReader{ctx: ctx, r: stream}, stream.GetSize(), up))
+	if err != nil {
+		return errors.Wrapf(classify(err), "error while store %s", fullPath)
+	}
+	return nil
+}
+
+func (d *Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
@@ -0,0 +1,154 @@
+package ftp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/errors"
+	"goftp.io/server/v2"
+	"goftp.io/server/v2/driver/file"
+)
+
+type discardLogger struct{}
+
+func (discardLogger) Print(sessionID string, message interface{})                  {}
+func (discardLogger) Printf(sessionID string, format string, v ...interface{})     {}
+func (discardLogger) PrintCommand(sessionID string, command string, params string) {}
+func (discardLogger) PrintResponse(sessionID string, code int, message string)     {}
+
+// startServer start an in-process ftp server which serves the root dir
+func startServer(t *testing.T, root string) int {
+	fileDriver, err := file.NewDriver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := server.NewServer(&server.Options{
+		Driver:   fileDriver,
+		Auth:     &server.SimpleAuth{Name: "alist", Password: "password"},
+		Perm:     server.NewSimplePerm("alist", "alist"),
+		Hostname: "127.0.0.1",
+		Logger:   discardLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(func() { _ = s.Shutdown() })
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestDriver(t *testing.T) {
+	root := t.TempDir()
+	port := startServer(t, root)
+	ctx := context.Background()
+	d := &Driver{}
+	err := d.Init(ctx, model.Account{
+		VirtualPath: "/ftp",
+		Driver:      "FTP",
+		Addition:    fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"username":"alist","password":"password","root_folder":"/"}`, port),
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	defer d.Drop(ctx)
+	rootObj := &model.Object{ID: d.RootFolder, Name: "root", IsFolder: true}
+
+	if err := d.MakeDir(ctx, rootObj, "dir"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	dir := &model.Object{ID: "/dir", Name: "dir", IsFolder: true}
+	content := []byte("0123456789")
+	err = d.Put(ctx, dir, &model.FileStream{
+		Obj:        model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+	}, func(int) {})
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if !utils.Exists(filepath.Join(root, "dir", "a.txt")) {
+		t.Fatalf("expected file uploaded to server")
+	}
+	objs, err := d.List(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "a.txt" || objs[0].GetSize() != int64(len(content)) {
+		t.Fatalf("unexpected list result: %+v", objs)
+	}
+	file := objs[0]
+
+	link, err := d.Link(ctx, file, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to get link: %+v", err)
+	}
+	for _, r := range []struct {
+		offset, length int64
+		expected       string
+	}{{2, 4, "2345"}, {7, -1, "789"}} {
+		rc, err := link.RangeReader.RangeRead(ctx, r.offset, r.length)
+		if err != nil {
+			t.Fatalf("failed to read range: %+v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(data) != r.expected {
+			t.Errorf("unexpected range data: %s, expected: %s", data, r.expected)
+		}
+	}
+
+	if err := d.Copy(ctx, file, rootObj); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); !bytes.Equal(b, content) {
+		t.Errorf("unexpected copied content: %s", b)
+	}
+	if err := d.Rename(ctx, dir, "renamed"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	objs, err = d.List(ctx, rootObj)
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected 2 objs, got %d", len(objs))
+	}
+	renamed := &model.Object{ID: "/renamed", Name: "renamed", IsFolder: true}
+	if err := d.Remove(ctx, renamed); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "renamed")); !os.IsNotExist(err) {
+		t.Errorf("expected dir removed, got %+v", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for code, retryable := range map[int]bool{
+		ftp.StatusNotAvailable:             true,
+		ftp.StatusCanNotOpenDataConnection: true,
+		ftp.StatusTransfertAborted:         true,
+		ftp.StatusFileActionIgnored:        true,
+		ftp.StatusFileUnavailable:          false,
+	} {
+		err := errors.Wrap(classify(&textproto.Error{Code: code, Msg: "reply"}), "error while store")
+		if task.IsRetryable(err) != retryable {
+			t.Errorf("expected reply %d retryable: %v", code, retryable)
+		}
+	}
+}
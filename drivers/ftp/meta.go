@@ -0,0 +1,30 @@
+package ftp
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	Host       string `json:"host" required:"true"`
+	Port       int    `json:"port" type:"number" default:"21"`
+	Username   string `json:"username" default:"anonymous"`
+	Password   string `json:"password"`
+	TLS        bool   `json:"tls" help:"use explicit TLS (FTPS)"`
+	SkipVerify bool   `json:"skip_verify" help:"skip verifying the certificate of the server"`
+	driver.RootFolderPath
+}
+
+var config = driver.Config{
+	Name:      "FTP",
+	LocalSort: true,
+	OnlyProxy: true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
@@ -0,0 +1,243 @@
+package ftp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	stdpath "path"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/jlaffaye/ftp"
+	pkgerr "github.com/pkg/errors"
+)
+
+// login dial a new connection to the server, a connection can only
+// transfer one file at a time, so Link and Put use their own connection
+func (d *Driver) login(ctx context.Context) (*ftp.ServerConn, error) {
+	addr := net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	opts := []ftp.DialOption{
+		ftp.DialWithContext(ctx),
+		ftp.DialWithTimeout(10 * time.Second),
+		ftp.DialWithDisabledMLSD(atomic.LoadInt32(&d.disableMLSD) == 1),
+	}
+	if d.TLS {
+		opts = append(opts, ftp.DialWithExplicitTLS(&tls.Config{
+			ServerName:         d.Host,
+			InsecureSkipVerify: d.SkipVerify,
+		}))
+	}
+	conn, err := ftp.Dial(addr, opts...)
+	if err != nil {
+		return nil, pkgerr.Wrapf(classify(err), "error while dial %s", addr)
+	}
+	if err = conn.Login(d.Username, d.Password); err != nil {
+		_ = conn.Quit()
+		return nil, pkgerr.Wrap(classify(err), "error while login")
+	}
+	return conn, nil
+}
+
+// withConn run fn with the shared connection, the connection is
+// re-established once if it's broken, e.g. closed by the server when idle
+func (d *Driver) withConn(ctx context.Context, fn func(conn *ftp.ServerConn) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		err := fn(d.conn)
+		if err == nil || isReply(err) {
+			return classify(err)
+		}
+		_ = d.conn.Quit()
+		d.conn = nil
+	}
+	conn, err := d.login(ctx)
+	if err != nil {
+		return err
+	}
+	d.conn = conn
+	return classify(fn(d.conn))
+}
+
+// isReply check if the err is a reply of the server rather than a network error
+func isReply(err error) bool {
+	var e *textproto.Error
+	return errors.As(err, &e)
+}
+
+// transientErr is a reply of the server which is worth retrying,
+// such as the server is busy or the data connection is broken
+type transientErr struct {
+	error
+}
+
+func (e transientErr) Unwrap() error {
+	return e.error
+}
+
+// Retryable implements task.Retryable
+func (e transientErr) Retryable() bool {
+	return true
+}
+
+// classify mark the transient replies of the server as retryable
+func classify(err error) error {
+	var e *textproto.Error
+	if errors.As(err, &e) {
+		switch e.Code {
+		case ftp.StatusNotAvailable, ftp.StatusCanNotOpenDataConnection, ftp.StatusTransfertAborted, ftp.StatusFileActionIgnored:
+			return transientErr{err}
+		}
+	}
+	return err
+}
+
+func isNotImplemented(err error) bool {
+	var e *textproto.Error
+	if errors.As(err, &e) {
+		switch e.Code {
+		case ftp.StatusBadCommand, ftp.StatusCommandNotImplemented, ftp.StatusNotImplemented, ftp.StatusNotImplementedParameter:
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Driver) list(ctx context.Context, path string) ([]*ftp.Entry, error) {
+	var entries []*ftp.Entry
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		var err error
+		entries, err = conn.List(path)
+		return err
+	})
+	// some servers announce MLST but don't implement MLSD well, fall back to LIST
+	if err != nil && isNotImplemented(err) && atomic.CompareAndSwapInt32(&d.disableMLSD, 0, 1) {
+		d.mu.Lock()
+		if d.conn != nil {
+			_ = d.conn.Quit()
+			d.conn = nil
+		}
+		d.mu.Unlock()
+		return d.list(ctx, path)
+	}
+	if err != nil {
+		return nil, pkgerr.Wrapf(err, "error while list %s", path)
+	}
+	files := make([]*ftp.Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.Name == "." || e.Name == ".." {
+			continue
+		}
+		files = append(files, e)
+	}
+	return files, nil
+}
+
+func entryToObj(e *ftp.Entry, path string) *model.Object {
+	return &model.Object{
+		ID:       path,
+		Name:     e.Name,
+		Size:     int64(e.Size),
+		Modified: e.Time,
+		IsFolder: e.Type == ftp.EntryTypeFolder,
+	}
+}
+
+// rangeReader retrieve the file from the offset with REST, every range uses
+// its own connection, since a connection can only transfer one file at a time
+func (d *Driver) rangeReader(path string) model.RangeReaderFunc {
+	return func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		conn, err := d.login(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := conn.RetrFrom(path, uint64(offset))
+		if err != nil {
+			_ = conn.Quit()
+			return nil, pkgerr.Wrapf(classify(err), "error while retrieve %s", path)
+		}
+		closer := closerFunc(func() error {
+			_ = resp.Close()
+			return conn.Quit()
+		})
+		var r io.Reader = resp
+		if length >= 0 {
+			r = io.LimitReader(resp, length)
+		}
+		return utils.ReadCloser{Reader: r, Closer: closer}, nil
+	}
+}
+
+type closerFunc func() error
+
+func (c closerFunc) Close() error {
+	return c()
+}
+
+// copyFile copy a remote file by retrieving and storing with two connections,
+// ftp has no server side copy
+func (d *Driver) copyFile(ctx context.Context, src, dst string) error {
+	srcConn, err := d.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Quit()
+	dstConn, err := d.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Quit()
+	resp, err := srcConn.Retr(src)
+	if err != nil {
+		return pkgerr.Wrapf(classify(err), "error while retrieve %s", src)
+	}
+	defer resp.Close()
+	if err = dstConn.Stor(dst, resp); err != nil {
+		return pkgerr.Wrapf(classify(err), "error while store %s", dst)
+	}
+	return nil
+}
+
+func (d *Driver) copyDir(ctx context.Context, src, dst string) error {
+	err := d.withConn(ctx, func(conn *ftp.ServerConn) error {
+		return conn.MakeDir(dst)
+	})
+	if err != nil {
+		return pkgerr.Wrapf(err, "error while make dir %s", dst)
+	}
+	entries, err := d.list(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		srcPath, dstPath := stdpath.Join(src, e.Name), stdpath.Join(dst, e.Name)
+		if e.Type == ftp.EntryTypeFolder {
+			err = d.copyDir(ctx, srcPath, dstPath)
+		} else {
+			err = d.copyFile(ctx, srcPath, dstPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ctxReader stop reading once the ctx is canceled, so that Stor can be interrupted
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -3,6 +3,7 @@ package local
 import (
 	"context"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -70,6 +71,7 @@ func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
 			Modified: f.ModTime(),
 			Size:     f.Size(),
 			IsFolder: f.IsDir(),
+			Hash:     cachedHash(filepath.Join(fullPath, f.Name()), f.Size(), f.ModTime()),
 		}
 		files = append(files, &file)
 	}
@@ -79,15 +81,18 @@ func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
 func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
 	f, err := os.Stat(path)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
 		return nil, errors.Wrapf(err, "error while stat %s", path)
 	}
-	file := model.Object{
+	file := object{model.Object{
 		ID:       path,
 		Name:     f.Name(),
 		Modified: f.ModTime(),
 		Size:     f.Size(),
 		IsFolder: f.IsDir(),
-	}
+	}}
 	return &file, nil
 }
 
@@ -153,6 +158,7 @@ func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
 	if err != nil {
 		return errors.Wrapf(err, "error while remove %s", obj.GetID())
 	}
+	hashCache.Delete(obj.GetID())
 	return nil
 }
 
@@ -168,15 +174,42 @@ func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStr
 			_ = os.Remove(fullPath)
 		}
 	}()
-	err = utils.CopyWithCtx(ctx, out, stream)
+	hasher := utils.NewMultiHasher(utils.StandardHashTypes...)
+	err = utils.CopyWithCtx(ctx, io.MultiWriter(out, hasher), stream)
 	if err != nil {
 		return errors.Wrapf(err, "error while copy file %s", fullPath)
 	}
+	if info, err := out.Stat(); err == nil {
+		hashCache.Store(fullPath, hashEntry{size: info.Size(), modified: info.ModTime(), hash: hasher.Sum()})
+	}
 	return nil
 }
 
+func (d *Driver) RapidHashTypes() []utils.HashType {
+	return []utils.HashType{utils.MD5}
+}
+
+// RapidPut copy the file with the same hashes put before instead of reading the stream
+func (d *Driver) RapidPut(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, hashes utils.HashInfo) (bool, error) {
+	src, e, ok := findByHash(stream.GetSize(), hashes)
+	if !ok {
+		return false, nil
+	}
+	fullPath := filepath.Join(dstDir.GetID(), stream.GetName())
+	if src != fullPath {
+		if err := copyFile(src, fullPath); err != nil {
+			return false, errors.Wrapf(err, "error while copy file %s", src)
+		}
+	}
+	if info, err := os.Stat(fullPath); err == nil {
+		hashCache.Store(fullPath, hashEntry{size: info.Size(), modified: info.ModTime(), hash: e.hash})
+	}
+	return true, nil
+}
+
 func (d Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
 	return nil, errs.NotSupport
 }
 
 var _ driver.Driver = (*Driver)(nil)
+var _ driver.RapidUploader = (*Driver)(nil)
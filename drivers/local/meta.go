@@ -14,6 +14,7 @@ var config = driver.Config{
 	OnlyLocal: true,
 	LocalSort: true,
 	NoCache:   true,
+	Hash:      true,
 }
 
 func New() driver.Driver {
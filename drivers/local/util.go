@@ -6,6 +6,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
 )
 
 // copyFile File copies a single file from src to dst
@@ -65,3 +72,78 @@ func copyDir(src string, dst string) error {
 	}
 	return nil
 }
+
+// hashEntry is the hashes of a file, it's valid until the file is modified
+type hashEntry struct {
+	size     int64
+	modified time.Time
+	hash     utils.HashInfo
+}
+
+// hashCache keep the hashes of the files put by the driver or computed when asked for,
+// by the full path. List only reports the cached ones, since computing the hashes of
+// every listed file is too slow
+var hashCache generic_sync.MapOf[string, hashEntry]
+
+func cachedHash(fullPath string, size int64, modified time.Time) utils.HashInfo {
+	e, ok := hashCache.Load(fullPath)
+	if !ok || e.size != size || !e.modified.Equal(modified) {
+		return nil
+	}
+	return e.hash
+}
+
+// fileHash return the standard hashes of the file, they are computed and cached
+// if the cached ones are missing or outdated
+func fileHash(fullPath string, size int64, modified time.Time) utils.HashInfo {
+	if hashes := cachedHash(fullPath, size, modified); hashes != nil {
+		return hashes
+	}
+	hashes, err := operations.FileHashes(nil, fullPath, utils.StandardHashTypes...)
+	if err != nil {
+		log.Warnf("failed compute hashes of %s: %+v", fullPath, err)
+		return nil
+	}
+	// the file may be modified while computing
+	info, err := os.Stat(fullPath)
+	if err != nil || info.Size() != size || !info.ModTime().Equal(modified) {
+		return nil
+	}
+	hashCache.Store(fullPath, hashEntry{size: size, modified: modified, hash: hashes})
+	return hashes
+}
+
+// object is a file got from Local, whose hashes are computed when they are asked for
+type object struct {
+	model.Object
+}
+
+func (o *object) GetHash() utils.HashInfo {
+	if o.IsFolder {
+		return nil
+	}
+	return fileHash(o.ID, o.Size, o.Modified)
+}
+
+// findByHash find the unmodified file with the size and hashes, which are cached
+// when the file is put or its hashes are asked for
+func findByHash(size int64, hashes utils.HashInfo) (string, hashEntry, bool) {
+	var (
+		found string
+		entry hashEntry
+	)
+	hashCache.Range(func(fullPath string, e hashEntry) bool {
+		if e.size != size {
+			return true
+		}
+		if equal, compared := e.hash.Equal(hashes); !equal || !compared {
+			return true
+		}
+		if info, err := os.Stat(fullPath); err != nil || cachedHash(fullPath, info.Size(), info.ModTime()) == nil {
+			return true
+		}
+		found, entry = fullPath, e
+		return false
+	})
+	return found, entry, found != ""
+}
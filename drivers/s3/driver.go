@@ -0,0 +1,298 @@
+package s3
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	client *s3.S3
+	sess   *session.Session
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.RootFolder = utils.StandardizePath(d.RootFolder)
+	d.sess, err = d.newSession()
+	if err != nil {
+		return errors.Wrap(err, "error while create session")
+	}
+	d.client = s3.New(d.sess)
+	// check the bucket is accessible
+	_, err = d.client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(d.Bucket)})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while access bucket %s", d.Bucket)
+	}
+	return nil
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	prefix := getDirKey(dir.GetID())
+	var files []model.Obj
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(d.Bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}
+	err := d.client.ListObjectsV2PagesWithContext(ctx, input, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, p := range output.CommonPrefixes {
+			name := stdpath.Base(aws.StringValue(p.Prefix))
+			files = append(files, &model.Object{
+				ID:       stdpath.Join(dir.GetID(), name),
+				Name:     name,
+				Modified: d.Modified,
+				IsFolder: true,
+			})
+		}
+		for _, o := range output.Contents {
+			key := aws.StringValue(o.Key)
+			name := stdpath.Base(key)
+			// the dir itself or its placeholder
+			if key == prefix || name == placeholder {
+				continue
+			}
+			files = append(files, &model.Object{
+				ID:       stdpath.Join(dir.GetID(), name),
+				Name:     name,
+				Size:     aws.Int64Value(o.Size),
+				Modified: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(statusErr(err), "error while list objects with prefix %s", prefix)
+	}
+	return files, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, d.RootFolder) {
+		return &model.Object{
+			ID:       d.RootFolder,
+			Name:     "root",
+			Modified: d.Modified,
+			IsFolder: true,
+		}, nil
+	}
+	head, err := d.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.Bucket),
+		Key:    aws.String(getKey(path)),
+	})
+	if err == nil {
+		return &model.Object{
+			ID:       path,
+			Name:     stdpath.Base(path),
+			Size:     aws.Int64Value(head.ContentLength),
+			Modified: aws.TimeValue(head.LastModified),
+		}, nil
+	}
+	if !isNotFound(err) {
+		return nil, errors.Wrapf(statusErr(err), "error while head object %s", path)
+	}
+	// maybe a virtual folder
+	output, err := d.client.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(d.Bucket),
+		Prefix:  aws.String(getDirKey(path)),
+		MaxKeys: aws.Int64(1),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(statusErr(err), "error while list objects of %s", path)
+	}
+	if aws.Int64Value(output.KeyCount) == 0 {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	return &model.Object{
+		ID:       path,
+		Name:     stdpath.Base(path),
+		Modified: d.Modified,
+		IsFolder: true,
+	}, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	req, _ := d.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(d.Bucket),
+		Key:    aws.String(getKey(file.GetID())),
+	})
+	expiration := time.Duration(d.SignURLExpire) * time.Hour
+	if expiration <= 0 {
+		expiration = time.Hour
+	}
+	url, err := req.Presign(expiration)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while presign %s", file.GetID())
+	}
+	// make sure the cached link expires before the presigned url
+	cacheExpiration := expiration - time.Minute
+	return &model.Link{
+		URL:        url,
+		Expiration: &cacheExpiration,
+	}, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	// S3 has no real dir, put a placeholder object so that the prefix exists
+	key := getDirKey(stdpath.Join(parentDir.GetID(), dirName)) + placeholder
+	_, err := d.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(d.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while make dir %s", key)
+	}
+	return nil
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	return d.moveObj(ctx, srcObj, dstPath)
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	dstPath := stdpath.Join(stdpath.Dir(srcObj.GetID()), newName)
+	return d.moveObj(ctx, srcObj, dstPath)
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	return d.copyObj(ctx, srcObj, dstPath)
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	if !obj.IsDir() {
+		return d.removeKey(ctx, getKey(obj.GetID()))
+	}
+	keys, err := d.listAllKeys(ctx, getDirKey(obj.GetID()))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := d.removeKey(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetID(), stream.GetName()))
+	uploader := s3manager.NewUploader(d.sess, func(u *s3manager.Uploader) {
+		if d.PartSize > 0 {
+			u.PartSize = int64(d.PartSize) * 1024 * 1024
+		}
+	})
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(d.Bucket),
+		Key:         aws.String(key),
+		Body:        driver.NewProgressReader(stream, stream.GetSize(), up),
+		ContentType: aws.String(stream.GetMimetype()),
+	})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while upload %s", key)
+	}
+	return nil
+}
+
+// InitUpload use the part size of the account if set, and fit it to the limits of S3
+func (d *Driver) InitUpload(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, upload *model.MultipartUpload) error {
+	upload.Key = getKey(stdpath.Join(dstDir.GetID(), stream.GetName()))
+	if d.PartSize > 0 {
+		upload.PartSize = int64(d.PartSize) * 1024 * 1024
+	}
+	if upload.PartSize < s3manager.MinUploadPartSize {
+		upload.PartSize = s3manager.MinUploadPartSize
+	}
+	if min := (upload.Size + s3manager.MaxUploadParts - 1) / s3manager.MaxUploadParts; upload.PartSize < min {
+		upload.PartSize = min
+	}
+	res, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(d.Bucket),
+		Key:         aws.String(upload.Key),
+		ContentType: aws.String(stream.GetMimetype()),
+	})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while create multipart upload %s", upload.Key)
+	}
+	upload.ID = aws.StringValue(res.UploadId)
+	return nil
+}
+
+func (d *Driver) UploadPart(ctx context.Context, upload *model.MultipartUpload, number int, part io.ReadSeeker, size int64) (*model.UploadedPart, error) {
+	res, err := d.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(d.Bucket),
+		Key:           aws.String(upload.Key),
+		UploadId:      aws.String(upload.ID),
+		PartNumber:    aws.Int64(int64(number)),
+		Body:          part,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(statusErr(err), "error while upload part %d of %s", number, upload.Key)
+	}
+	return &model.UploadedPart{Number: number, ETag: aws.StringValue(res.ETag), Size: size}, nil
+}
+
+func (d *Driver) CompleteUpload(ctx context.Context, upload *model.MultipartUpload, parts []model.UploadedPart) error {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(int64(part.Number)),
+		})
+	}
+	_, err := d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(d.Bucket),
+		Key:             aws.String(upload.Key),
+		UploadId:        aws.String(upload.ID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return errors.Wrapf(statusErr(err), "error while complete multipart upload %s", upload.Key)
+}
+
+func (d *Driver) AbortUpload(ctx context.Context, upload *model.MultipartUpload) error {
+	_, err := d.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(d.Bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.ID),
+	})
+	return errors.Wrapf(statusErr(err), "error while abort multipart upload %s", upload.Key)
+}
+
+func (d Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.Getter = (*Driver)(nil)
+var _ driver.MultipartUploader = (*Driver)(nil)
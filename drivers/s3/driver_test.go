@@ -0,0 +1,222 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/pkg/errors"
+)
+
+func newTestDriver(t *testing.T) *Driver {
+	backend := s3mem.New()
+	if err := backend.CreateBucket("alist"); err != nil {
+		t.Fatalf("failed to create bucket: %+v", err)
+	}
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+	d := &Driver{}
+	err := d.Init(context.Background(), model.Account{
+		VirtualPath: "/s3",
+		Driver:      "S3",
+		Addition: fmt.Sprintf(`{"root_folder":"/prefix","bucket":"alist","endpoint":"%s","region":"us-east-1",`+
+			`"access_key_id":"id","secret_access_key":"secret","force_path_style":true,"sign_url_expire":1,"part_size":5}`, server.URL),
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	return d
+}
+
+func put(t *testing.T, d *Driver, dir model.Obj, name string, data []byte) {
+	stream := &model.FileStream{
+		Obj:        model.Object{Name: name, Size: int64(len(data)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+		Mimetype:   "application/octet-stream",
+	}
+	progress := 0
+	err := d.Put(context.Background(), dir, stream, func(p int) {
+		progress = p
+	})
+	if err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+	if progress != 100 {
+		t.Errorf("expected progress 100, got %d", progress)
+	}
+}
+
+func listNames(t *testing.T, d *Driver, path string) map[string]bool {
+	dir, err := d.Get(context.Background(), path)
+	if err != nil {
+		t.Fatalf("failed to get %s: %+v", path, err)
+	}
+	objs, err := d.List(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("failed to list %s: %+v", path, err)
+	}
+	names := make(map[string]bool)
+	for _, obj := range objs {
+		names[obj.GetName()] = obj.IsDir()
+	}
+	return names
+}
+
+func TestDriver(t *testing.T) {
+	d := newTestDriver(t)
+	ctx := context.Background()
+	root, err := d.Get(ctx, "/prefix")
+	if err != nil {
+		t.Fatalf("failed to get root: %+v", err)
+	}
+	if err := d.MakeDir(ctx, root, "dir"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	// larger than part size, so it will be uploaded by multipart
+	big := bytes.Repeat([]byte("a"), 6*1024*1024)
+	put(t, d, root, "big.bin", big)
+	dir, err := d.Get(ctx, "/prefix/dir")
+	if err != nil || !dir.IsDir() {
+		t.Fatalf("failed to get dir: %+v", err)
+	}
+	put(t, d, dir, "small.txt", []byte("hello"))
+
+	names := listNames(t, d, "/prefix")
+	if len(names) != 2 || !names["dir"] || names["big.bin"] {
+		t.Errorf("unexpected root objs: %+v", names)
+	}
+
+	file, err := d.Get(ctx, "/prefix/big.bin")
+	if err != nil {
+		t.Fatalf("failed to get file: %+v", err)
+	}
+	if file.GetSize() != int64(len(big)) {
+		t.Errorf("expected size %d, got %d", len(big), file.GetSize())
+	}
+	link, err := d.Link(ctx, file, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to get link: %+v", err)
+	}
+	if link.Expiration == nil || *link.Expiration <= 0 {
+		t.Errorf("expected link has an expiration")
+	}
+	res, err := http.Get(link.URL)
+	if err != nil {
+		t.Fatalf("failed to get presigned url: %+v", err)
+	}
+	data, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if !bytes.Equal(data, big) {
+		t.Errorf("content of presigned url is not equal, got %d bytes", len(data))
+	}
+
+	small, err := d.Get(ctx, "/prefix/dir/small.txt")
+	if err != nil {
+		t.Fatalf("failed to get small file: %+v", err)
+	}
+	if err := d.Copy(ctx, small, root); err != nil {
+		t.Fatalf("failed to copy file: %+v", err)
+	}
+	if err := d.Rename(ctx, dir, "renamed"); err != nil {
+		t.Fatalf("failed to rename dir: %+v", err)
+	}
+	names = listNames(t, d, "/prefix/renamed")
+	if _, ok := names["small.txt"]; !ok {
+		t.Errorf("expected small.txt in renamed dir, got %+v", names)
+	}
+	if _, err := d.Get(ctx, "/prefix/dir"); err == nil {
+		t.Errorf("expected old dir removed after rename")
+	}
+	renamed, _ := d.Get(ctx, "/prefix/renamed")
+	if err := d.Remove(ctx, renamed); err != nil {
+		t.Fatalf("failed to remove dir: %+v", err)
+	}
+	names = listNames(t, d, "/prefix")
+	if len(names) != 2 || names["small.txt"] {
+		t.Errorf("unexpected root objs after remove: %+v", names)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	d := newTestDriver(t)
+	ctx := context.Background()
+	root, err := d.Get(ctx, "/prefix")
+	if err != nil {
+		t.Fatalf("failed to get root: %+v", err)
+	}
+	data := bytes.Repeat([]byte("0123456789"), 1100*1024)
+	stream := &model.FileStream{
+		Obj:      model.Object{Name: "multipart.bin", Size: int64(len(data)), Modified: time.Now()},
+		Mimetype: "application/octet-stream",
+	}
+	upload := &model.MultipartUpload{Name: stream.GetName(), Size: stream.GetSize(), PartSize: 1024}
+	if err := d.InitUpload(ctx, root, stream, upload); err != nil {
+		t.Fatalf("failed to init upload: %+v", err)
+	}
+	// the part size of the account is used
+	if upload.PartSize != 5*1024*1024 {
+		t.Fatalf("unexpected part size %d", upload.PartSize)
+	}
+	// the parts can be uploaded out of order
+	parts := make([]model.UploadedPart, 3)
+	for _, i := range []int{2, 0, 1} {
+		start, end := int64(i)*upload.PartSize, int64(i+1)*upload.PartSize
+		if end > upload.Size {
+			end = upload.Size
+		}
+		part, err := d.UploadPart(ctx, upload, i+1, bytes.NewReader(data[start:end]), end-start)
+		if err != nil {
+			t.Fatalf("failed to upload part %d: %+v", i+1, err)
+		}
+		parts[i] = *part
+	}
+	if err := d.CompleteUpload(ctx, upload, parts); err != nil {
+		t.Fatalf("failed to complete upload: %+v", err)
+	}
+	file, err := d.Get(ctx, "/prefix/multipart.bin")
+	if err != nil || file.GetSize() != int64(len(data)) {
+		t.Fatalf("unexpected uploaded file: %+v %+v", file, err)
+	}
+
+	aborted := &model.MultipartUpload{Name: "aborted.bin", Size: 10, PartSize: 5}
+	stream.Obj = model.Object{Name: aborted.Name, Size: aborted.Size, Modified: time.Now()}
+	if err := d.InitUpload(ctx, root, stream, aborted); err != nil {
+		t.Fatalf("failed to init upload: %+v", err)
+	}
+	if err := d.AbortUpload(ctx, aborted); err != nil {
+		t.Fatalf("failed to abort upload: %+v", err)
+	}
+	if _, err := d.Get(ctx, "/prefix/aborted.bin"); err == nil {
+		t.Errorf("expected no object of aborted upload")
+	}
+}
+
+func TestStatusErr(t *testing.T) {
+	for _, c := range []struct {
+		err       error
+		retryable bool
+	}{
+		{awserr.NewRequestFailure(awserr.New("SlowDown", "reduce your request rate", nil), http.StatusServiceUnavailable, "id"), true},
+		{awserr.NewRequestFailure(awserr.New("RequestLimitExceeded", "too many requests", nil), http.StatusTooManyRequests, "id"), true},
+		{awserr.NewRequestFailure(awserr.New("AccessDenied", "access denied", nil), http.StatusForbidden, "id"), false},
+		// the failed part nested by the uploader
+		{awserr.New("MultipartUpload", "upload multipart failed",
+			awserr.NewRequestFailure(awserr.New("InternalError", "internal error", nil), http.StatusInternalServerError, "id")), true},
+	} {
+		err := errors.Wrap(statusErr(c.err), "error while upload")
+		if task.IsRetryable(err) != c.retryable {
+			t.Errorf("expected %v retryable: %v", c.err, c.retryable)
+		}
+	}
+}
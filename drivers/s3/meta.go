@@ -0,0 +1,31 @@
+package s3
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	driver.RootFolderPath
+	Bucket          string `json:"bucket" required:"true"`
+	Endpoint        string `json:"endpoint" required:"true"`
+	Region          string `json:"region" default:"us-east-1"`
+	AccessKeyID     string `json:"access_key_id" required:"true"`
+	SecretAccessKey string `json:"secret_access_key" required:"true"`
+	ForcePathStyle  bool   `json:"force_path_style" default:"true" help:"required by most S3-compatible endpoints such as MinIO and Ceph"`
+	SignURLExpire   int    `json:"sign_url_expire" type:"number" default:"4" help:"presigned url expiration, in hours"`
+	PartSize        int    `json:"part_size" type:"number" default:"5" help:"part size of multipart upload, in MB"`
+}
+
+var config = driver.Config{
+	Name:      "S3",
+	LocalSort: true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// placeholder is the name of empty object put in a dir made by MakeDir,
+// some S3-compatible servers don't keep the trailing slash of keys, so `dir/` can't be used
+const placeholder = ".placeholder"
+
+func (d *Driver) newSession() (*session.Session, error) {
+	cfg := &aws.Config{
+		Credentials:      credentials.NewStaticCredentials(d.AccessKeyID, d.SecretAccessKey, ""),
+		Region:           aws.String(d.Region),
+		Endpoint:         aws.String(d.Endpoint),
+		S3ForcePathStyle: aws.Bool(d.ForcePathStyle),
+	}
+	return session.NewSession(cfg)
+}
+
+// getKey convert the actual path to the object key, such as /a/b => a/b
+func getKey(path string) string {
+	return strings.TrimPrefix(path, "/")
+}
+
+// getDirKey convert the actual path of a dir to the key prefix, such as /a => a/ and / => ""
+func getDirKey(path string) string {
+	key := strings.TrimSuffix(getKey(path), "/")
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func isNotFound(err error) bool {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+// statusErr convert the failed requests to errs.StatusError, so that the tasks
+// retry the server errors and rate limits, the uploader nests them in its own errors
+func statusErr(err error) error {
+	for e := err; e != nil; {
+		if rf, ok := e.(awserr.RequestFailure); ok {
+			return errs.StatusError{Code: rf.StatusCode(), Msg: err.Error()}
+		}
+		aerr, ok := e.(awserr.Error)
+		if !ok {
+			break
+		}
+		e = aerr.OrigErr()
+	}
+	return err
+}
+
+// listAllKeys list all keys with the prefix recursively
+func (d *Driver) listAllKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := d.client.ListObjectsV2PagesWithContext(ctx, input, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range output.Contents {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(statusErr(err), "error while list objects with prefix %s", prefix)
+	}
+	return keys, nil
+}
+
+func (d *Driver) copyKey(ctx context.Context, srcKey, dstKey string) error {
+	source := (&url.URL{Path: stdpath.Join(d.Bucket, srcKey)}).EscapedPath()
+	_, err := d.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(d.Bucket),
+		CopySource: aws.String(source),
+		Key:        aws.String(dstKey),
+	})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while copy %s to %s", srcKey, dstKey)
+	}
+	return nil
+}
+
+func (d *Driver) removeKey(ctx context.Context, key string) error {
+	_, err := d.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return errors.Wrapf(statusErr(err), "error while remove %s", key)
+	}
+	return nil
+}
+
+func (d *Driver) copyObj(ctx context.Context, srcObj model.Obj, dstPath string) error {
+	if !srcObj.IsDir() {
+		return d.copyKey(ctx, getKey(srcObj.GetID()), getKey(dstPath))
+	}
+	srcPrefix, dstPrefix := getDirKey(srcObj.GetID()), getDirKey(dstPath)
+	keys, err := d.listAllKeys(ctx, srcPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = d.copyKey(ctx, key, dstPrefix+strings.TrimPrefix(key, srcPrefix))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveObj S3 doesn't support move, so copy then remove
+func (d *Driver) moveObj(ctx context.Context, srcObj model.Obj, dstPath string) error {
+	err := d.copyObj(ctx, srcObj, dstPath)
+	if err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
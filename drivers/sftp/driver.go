@@ -0,0 +1,197 @@
+package sftp
+
+import (
+	"context"
+	"os"
+	stdpath "path"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	// mu protects the clients, which are replaced when the connection is lost,
+	// it's a pointer so that the Driver can be copied by the value receivers
+	mu        *sync.Mutex
+	sshClient *ssh.Client
+	client    *sftp.Client
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.RootFolder = utils.StandardizePath(d.RootFolder)
+	d.mu = &sync.Mutex{}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hostKey, err := d.login(ctx)
+	if err != nil {
+		return err
+	}
+	// the key of the first connection is pinned, it's saved with the account after Init
+	d.HostKey = hostKey
+	return nil
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	if d.mu == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.close()
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	var rawFiles []os.FileInfo
+	err := d.withClient(ctx, func(client *sftp.Client) (err error) {
+		rawFiles, err = client.ReadDir(dir.GetID())
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while read dir %s", dir.GetID())
+	}
+	files := make([]model.Obj, 0, len(rawFiles))
+	for _, f := range rawFiles {
+		files = append(files, fileToObj(f, stdpath.Join(dir.GetID(), f.Name())))
+	}
+	return files, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	var f os.FileInfo
+	err := d.withClient(ctx, func(client *sftp.Client) (err error) {
+		f, err = client.Stat(path)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		return nil, errors.Wrapf(err, "error while stat %s", path)
+	}
+	obj := fileToObj(f, path)
+	if utils.PathEqual(path, d.RootFolder) {
+		obj.Name = "root"
+	}
+	return obj, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return &model.Link{RangeReader: d.rangeReader(file.GetID())}, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	fullPath := stdpath.Join(parentDir.GetID(), dirName)
+	err := d.withClient(ctx, func(client *sftp.Client) error {
+		return client.MkdirAll(fullPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while make dir %s", fullPath)
+	}
+	return nil
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	err := d.withClient(ctx, func(client *sftp.Client) error {
+		return client.Rename(srcPath, dstPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while move %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(stdpath.Dir(srcPath), newName)
+	err := d.withClient(ctx, func(client *sftp.Client) error {
+		return client.Rename(srcPath, dstPath)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while rename %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcPath := srcObj.GetID()
+	dstPath := stdpath.Join(dstDir.GetID(), srcObj.GetName())
+	err := d.withClient(ctx, func(client *sftp.Client) error {
+		if srcObj.IsDir() {
+			return copyDir(ctx, client, srcPath, dstPath)
+		}
+		return copyFile(ctx, client, srcPath, dstPath)
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "error while copy %s to %s", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	err := d.withClient(ctx, func(client *sftp.Client) error {
+		if obj.IsDir() {
+			return removeAll(client, obj.GetID())
+		}
+		return client.Remove(obj.GetID())
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while remove %s", obj.GetID())
+	}
+	return nil
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	fullPath := stdpath.Join(dstDir.GetID(), stream.GetName())
+	var out *sftp.File
+	err := d.withClient(ctx, func(client *sftp.Client) (err error) {
+		out, err = client.Create(fullPath)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while create file %s", fullPath)
+	}
+	defer func() {
+		_ = out.Close()
+		if errors.Is(err, context.Canceled) {
+			_ = d.withClient(context.Background(), func(client *sftp.Client) error {
+				return client.Remove(fullPath)
+			})
+		}
+	}()
+	err = utils.CopyWithCtx(ctx, out, driver.NewProgressReader(stream, stream.GetSize(), up))
+	if err != nil {
+		return errors.Wrapf(err, "error while copy file %s", fullPath)
+	}
+	return nil
+}
+
+func (d Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.Getter = (*Driver)(nil)
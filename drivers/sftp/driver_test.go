@@ -0,0 +1,208 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startServer start an in-process sftp server which serves the local file system
+func startServer(t *testing.T) int {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "alist" && string(pass) == "password" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				_ = req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}(requests)
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			return
+		}
+		go func() {
+			_ = server.Serve()
+			_ = server.Close()
+		}()
+	}
+}
+
+func newTestDriver(t *testing.T) (*Driver, string) {
+	port := startServer(t)
+	root := t.TempDir()
+	d := &Driver{}
+	err := d.Init(context.Background(), model.Account{
+		VirtualPath: "/sftp",
+		Driver:      "SFTP",
+		Addition:    fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"username":"alist","password":"password","root_folder":"%s"}`, port, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	t.Cleanup(func() { _ = d.Drop(context.Background()) })
+	return d, root
+}
+
+func TestDriver(t *testing.T) {
+	d, root := newTestDriver(t)
+	ctx := context.Background()
+	rootObj, err := d.Get(ctx, d.RootFolder)
+	if err != nil || !rootObj.IsDir() {
+		t.Fatalf("failed to get root: %+v", err)
+	}
+	if err := d.MakeDir(ctx, rootObj, "dir"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	dir, err := d.Get(ctx, d.RootFolder+"/dir")
+	if err != nil {
+		t.Fatalf("failed to get dir: %+v", err)
+	}
+	content := []byte("0123456789")
+	err = d.Put(ctx, dir, &model.FileStream{
+		Obj:        model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+	}, func(int) {})
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if !utils.Exists(filepath.Join(root, "dir", "a.txt")) {
+		t.Fatalf("expected file uploaded to server")
+	}
+	file, err := d.Get(ctx, d.RootFolder+"/dir/a.txt")
+	if err != nil {
+		t.Fatalf("failed to get file: %+v", err)
+	}
+
+	link, err := d.Link(ctx, file, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to get link: %+v", err)
+	}
+	for _, r := range []struct {
+		offset, length int64
+		expected       string
+	}{{2, 4, "2345"}, {7, -1, "789"}} {
+		rc, err := link.RangeReader.RangeRead(ctx, r.offset, r.length)
+		if err != nil {
+			t.Fatalf("failed to read range: %+v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(data) != r.expected {
+			t.Errorf("unexpected range data: %s, expected: %s", data, r.expected)
+		}
+	}
+
+	if err := d.Copy(ctx, file, rootObj); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	if err := d.Rename(ctx, dir, "renamed"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	objs, err := d.List(ctx, rootObj)
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected 2 objs, got %d", len(objs))
+	}
+	renamed, _ := d.Get(ctx, d.RootFolder+"/renamed")
+	if err := d.Remove(ctx, renamed); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "renamed")); !os.IsNotExist(err) {
+		t.Errorf("expected dir removed, got %+v", err)
+	}
+}
+
+func TestHostKeyAndReconnect(t *testing.T) {
+	d, _ := newTestDriver(t)
+	ctx := context.Background()
+	if !strings.HasPrefix(d.HostKey, "SHA256:") {
+		t.Fatalf("expected the host key pinned, got %s", d.HostKey)
+	}
+	hostKey := d.HostKey
+	// the ssh session is dropped, and the addition is read while reconnecting
+	_ = d.sshClient.Close()
+	read := make(chan string)
+	go func() {
+		read <- d.GetAddition().(Addition).HostKey
+	}()
+	if _, err := d.Get(ctx, d.RootFolder); err != nil {
+		t.Fatalf("expected reconnect after the session dropped: %+v", err)
+	}
+	if key := <-read; key != hostKey || d.HostKey != hostKey {
+		t.Errorf("expected the pinned host key %s unchanged, got %s", hostKey, d.HostKey)
+	}
+
+	other := &Driver{}
+	err := other.Init(ctx, model.Account{
+		VirtualPath: "/sftp_mitm",
+		Driver:      "SFTP",
+		Addition:    fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"username":"alist","password":"password","host_key":"SHA256:other"}`, d.Port),
+	})
+	if err == nil {
+		_ = other.Drop(ctx)
+		t.Errorf("expected error while the host key mismatch")
+	}
+}
@@ -0,0 +1,30 @@
+package sftp
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	Host       string `json:"host" required:"true"`
+	Port       int    `json:"port" type:"number" default:"22"`
+	Username   string `json:"username" required:"true"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key" type:"text" help:"private key in PEM format, used instead of password if set"`
+	HostKey    string `json:"host_key" help:"SHA256 fingerprint of the host key, such as SHA256:xxx, the key of the first connection is pinned if empty"`
+	driver.RootFolderPath
+}
+
+var config = driver.Config{
+	Name:      "SFTP",
+	LocalSort: true,
+	OnlyProxy: true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
@@ -0,0 +1,210 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// login connect to the server and return the fingerprint of its host key,
+// it must be called with the lock held
+func (d *Driver) login(ctx context.Context) (string, error) {
+	var auth []ssh.AuthMethod
+	if d.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(d.PrivateKey))
+		if err != nil {
+			return "", errors.Wrap(err, "error while parse private key")
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if d.Password != "" {
+		auth = append(auth, ssh.Password(d.Password))
+	}
+	var fingerprint string
+	clientConfig := &ssh.ClientConfig{
+		User:            d.Username,
+		Auth:            auth,
+		HostKeyCallback: checkHostKey(d.HostKey, &fingerprint),
+		Timeout:         10 * time.Second,
+	}
+	addr := net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "error while dial %s", addr)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		_ = conn.Close()
+		return "", errors.Wrapf(err, "error while ssh handshake with %s", addr)
+	}
+	d.sshClient = ssh.NewClient(c, chans, reqs)
+	d.client, err = sftp.NewClient(d.sshClient)
+	if err != nil {
+		_ = d.sshClient.Close()
+		d.sshClient = nil
+		return "", errors.Wrap(err, "error while create sftp client")
+	}
+	return fingerprint, nil
+}
+
+// checkHostKey verify the key of the server by the expected fingerprint, any key
+// is accepted if it's not set. the fingerprint of the key is stored to got, rather than
+// the driver, since the addition may be read by others while reconnecting
+func checkHostKey(expected string, got *string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+		if expected != "" && fingerprint != expected {
+			return errors.Errorf("host key of %s mismatch, expected %s, got %s", hostname, expected, fingerprint)
+		}
+		*got = fingerprint
+		return nil
+	}
+}
+
+// withClient run fn with the client, the connection is re-established
+// once if it's lost, e.g. the ssh session is dropped by the server
+func (d *Driver) withClient(ctx context.Context, fn func(client *sftp.Client) error) error {
+	client, err := d.getClient(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(client)
+	if !isConnLost(err) {
+		return err
+	}
+	client, err = d.getClient(ctx, client)
+	if err != nil {
+		return err
+	}
+	return fn(client)
+}
+
+// getClient return the client connected, the broken one is replaced by a new one
+// unless it has been replaced by others
+func (d *Driver) getClient(ctx context.Context, broken *sftp.Client) (*sftp.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != nil && d.client != broken {
+		return d.client, nil
+	}
+	d.close()
+	if _, err := d.login(ctx); err != nil {
+		return nil, err
+	}
+	return d.client, nil
+}
+
+// close the connection, it must be called with the lock held
+func (d *Driver) close() {
+	if d.client != nil {
+		_ = d.client.Close()
+		d.client = nil
+	}
+	if d.sshClient != nil {
+		_ = d.sshClient.Close()
+		d.sshClient = nil
+	}
+}
+
+func isConnLost(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
+
+func fileToObj(f os.FileInfo, path string) *model.Object {
+	return &model.Object{
+		ID:       path,
+		Name:     f.Name(),
+		Size:     f.Size(),
+		Modified: f.ModTime(),
+		IsFolder: f.IsDir(),
+	}
+}
+
+// rangeReader open the file and seek to the offset for every range
+func (d *Driver) rangeReader(path string) model.RangeReaderFunc {
+	return func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		var f *sftp.File
+		err := d.withClient(ctx, func(client *sftp.Client) (err error) {
+			f, err = client.Open(path)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while open %s", path)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrapf(err, "error while seek to %d", offset)
+		}
+		if length < 0 {
+			return f, nil
+		}
+		return utils.ReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+	}
+}
+
+// copyFile copy a remote file by reading and writing, sftp has no server side copy
+func copyFile(ctx context.Context, client *sftp.Client, src, dst string) error {
+	srcFile, err := client.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "error while open %s", src)
+	}
+	defer srcFile.Close()
+	dstFile, err := client.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "error while create %s", dst)
+	}
+	defer dstFile.Close()
+	return utils.CopyWithCtx(ctx, dstFile, srcFile)
+}
+
+func copyDir(ctx context.Context, client *sftp.Client, src, dst string) error {
+	if err := client.MkdirAll(dst); err != nil {
+		return errors.Wrapf(err, "error while make dir %s", dst)
+	}
+	files, err := client.ReadDir(src)
+	if err != nil {
+		return errors.Wrapf(err, "error while read dir %s", src)
+	}
+	for _, f := range files {
+		srcPath, dstPath := stdpath.Join(src, f.Name()), stdpath.Join(dst, f.Name())
+		if f.IsDir() {
+			err = copyDir(ctx, client, srcPath, dstPath)
+		} else {
+			err = copyFile(ctx, client, srcPath, dstPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeAll(client *sftp.Client, path string) error {
+	files, err := client.ReadDir(path)
+	if err != nil {
+		return errors.Wrapf(err, "error while read dir %s", path)
+	}
+	for _, f := range files {
+		p := stdpath.Join(path, f.Name())
+		if f.IsDir() {
+			err = removeAll(client, p)
+		} else {
+			err = client.Remove(p)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error while remove %s", p)
+		}
+	}
+	return client.RemoveDirectory(path)
+}
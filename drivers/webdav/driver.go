@@ -0,0 +1,133 @@
+package webdav
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Driver struct {
+	model.Account
+	Addition
+	client  *http.Client
+	address *url.URL
+}
+
+func (d Driver) Config() driver.Config {
+	return config
+}
+
+func (d *Driver) Init(ctx context.Context, account model.Account) error {
+	d.Account = account
+	err := utils.Json.UnmarshalFromString(d.Account.Addition, &d.Addition)
+	if err != nil {
+		return errors.Wrap(err, "error while unmarshal addition")
+	}
+	d.RootFolder = utils.StandardizePath(d.RootFolder)
+	d.address, err = url.Parse(d.Address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address %s", d.Address)
+	}
+	d.newClient()
+	// check the root folder is accessible
+	_, err = d.propfind(ctx, d.RootFolder, "0")
+	if err != nil {
+		return errors.WithMessagef(err, "error while access root folder %s", d.RootFolder)
+	}
+	return nil
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return d.Addition
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj) ([]model.Obj, error) {
+	objs, err := d.propfind(ctx, dir.GetID(), "1")
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while list %s", dir.GetID())
+	}
+	files := make([]model.Obj, 0, len(objs)-1)
+	for _, obj := range objs[1:] {
+		files = append(files, obj)
+	}
+	return files, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	objs, err := d.propfind(ctx, path, "0")
+	if err != nil {
+		return nil, err
+	}
+	obj := objs[0]
+	if utils.PathEqual(path, d.RootFolder) {
+		obj.Name = "root"
+	}
+	return obj, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	// the Range header of the request is forwarded by the proxy
+	return &model.Link{
+		URL:    d.getURL(file.GetID()),
+		Header: d.authHeader(),
+	}, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return d.do(ctx, "MKCOL", stdpath.Join(parentDir.GetID(), dirName), nil)
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.copyMove(ctx, "MOVE", srcObj.GetID(), stdpath.Join(dstDir.GetID(), srcObj.GetName()))
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.copyMove(ctx, "MOVE", srcObj.GetID(), stdpath.Join(stdpath.Dir(srcObj.GetID()), newName))
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.copyMove(ctx, "COPY", srcObj.GetID(), stdpath.Join(dstDir.GetID(), srcObj.GetName()))
+}
+
+func (d *Driver) copyMove(ctx context.Context, method, srcPath, dstPath string) error {
+	return d.do(ctx, method, srcPath, func(req *http.Request) {
+		req.Header.Set("Destination", d.getURL(dstPath))
+		req.Header.Set("Overwrite", "F")
+	})
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	return d.do(ctx, http.MethodDelete, obj.GetID(), nil)
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	fullPath := stdpath.Join(dstDir.GetID(), stream.GetName())
+	res, err := d.request(ctx, http.MethodPut, fullPath, driver.NewProgressReader(stream, stream.GetSize(), up), func(req *http.Request) {
+		req.ContentLength = stream.GetSize()
+		if stream.GetMimetype() != "" {
+			req.Header.Set("Content-Type", stream.GetMimetype())
+		}
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "error while upload %s", fullPath)
+	}
+	return res.Body.Close()
+}
+
+func (d *Driver) Other(ctx context.Context, data interface{}) (interface{}, error) {
+	return nil, errs.NotSupport
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.Getter = (*Driver)(nil)
@@ -0,0 +1,191 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	davserver "github.com/alist-org/alist/v3/server/webdav"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+	// the streams put to Local are cached here to compute the hashes
+	conf.Conf.TempDir = os.TempDir()
+}
+
+// startServer serve a local account with the webdav handler of alist itself
+func startServer(t *testing.T, root string) string {
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "Local",
+		VirtualPath: "/local",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local account: %+v", err)
+	}
+	handler := &davserver.Handler{
+		Prefix:     "/dav",
+		LockSystem: davserver.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				t.Logf("%s %s: %+v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	user := &model.User{Username: "alist", BasePath: "/"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "alist" || p != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/dav/local"
+}
+
+func TestDriver(t *testing.T) {
+	root := t.TempDir()
+	address := startServer(t, root)
+	ctx := context.Background()
+	d := &Driver{}
+	err := d.Init(ctx, model.Account{
+		VirtualPath: "/webdav",
+		Driver:      "WebDAV",
+		Addition:    fmt.Sprintf(`{"address":"%s","username":"alist","password":"password","root_folder":"/"}`, address),
+	})
+	if err != nil {
+		t.Fatalf("failed to init driver: %+v", err)
+	}
+	rootObj, err := d.Get(ctx, d.RootFolder)
+	if err != nil || !rootObj.IsDir() {
+		t.Fatalf("failed to get root: %+v", err)
+	}
+	if err := d.MakeDir(ctx, rootObj, "dir"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	dir, err := d.Get(ctx, "/dir")
+	if err != nil || !dir.IsDir() {
+		t.Fatalf("failed to get dir: %+v", err)
+	}
+	content := []byte("0123456789")
+	err = d.Put(ctx, dir, &model.FileStream{
+		Obj:        model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+	}, func(int) {})
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	objs, err := d.List(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "a.txt" || objs[0].GetSize() != int64(len(content)) {
+		t.Fatalf("unexpected list result: %+v", objs)
+	}
+	file := objs[0]
+
+	link, err := d.Link(ctx, file, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to get link: %+v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, link.URL, nil)
+	req.Header = link.Header.Clone()
+	req.Header.Set("Range", "bytes=2-5")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to get file: %+v", err)
+	}
+	data, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if string(data) != "2345" || res.StatusCode != http.StatusPartialContent {
+		t.Errorf("unexpected range data: %s, status: %d", data, res.StatusCode)
+	}
+
+	if err := d.Copy(ctx, file, rootObj); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); !bytes.Equal(b, content) {
+		t.Errorf("unexpected copied content: %s", b)
+	}
+	// the COPY of the server copies to the dst name and overwrites only if asked
+	if err := d.MakeDir(ctx, dir, "sub"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "dir", "sub", "c.txt"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		dst       string
+		overwrite string
+		status    int
+		content   string
+	}{
+		{"b.txt", "F", http.StatusCreated, string(content)},
+		{"c.txt", "F", http.StatusPreconditionFailed, "c"},
+		{"c.txt", "T", http.StatusNoContent, string(content)},
+	} {
+		req, _ := http.NewRequest("COPY", address+"/dir/a.txt", nil)
+		req.SetBasicAuth("alist", "password")
+		req.Header.Set("Destination", address+"/dir/sub/"+c.dst)
+		req.Header.Set("Overwrite", c.overwrite)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to copy: %+v", err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != c.status {
+			t.Errorf("expected status %d of copying to %s, got %d", c.status, c.dst, res.StatusCode)
+		}
+		if b, _ := os.ReadFile(filepath.Join(root, "dir", "sub", c.dst)); string(b) != c.content {
+			t.Errorf("unexpected content of %s: %s", c.dst, b)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "dir", "sub", "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected no file of the src name, got %+v", err)
+	}
+	if err := d.Rename(ctx, dir, "renamed"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	objs, err = d.List(ctx, rootObj)
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected 2 objs, got %d", len(objs))
+	}
+	renamed, err := d.Get(ctx, "/renamed")
+	if err != nil {
+		t.Fatalf("failed to get renamed dir: %+v", err)
+	}
+	if err := d.Remove(ctx, renamed); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "renamed")); !os.IsNotExist(err) {
+		t.Errorf("expected dir removed, got %+v", err)
+	}
+	if _, err := d.Get(ctx, "/renamed"); err == nil {
+		t.Errorf("expected object not found")
+	}
+}
@@ -0,0 +1,28 @@
+package webdav
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/operations"
+)
+
+type Addition struct {
+	Address    string `json:"address" required:"true" help:"url of the webdav server, such as https://example.com/dav"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	SkipVerify bool   `json:"skip_verify" help:"skip verifying the certificate of the server"`
+	driver.RootFolderPath
+}
+
+var config = driver.Config{
+	Name:      "WebDAV",
+	LocalSort: true,
+	OnlyProxy: true,
+}
+
+func New() driver.Driver {
+	return &Driver{}
+}
+
+func init() {
+	operations.RegisterDriver(config, New)
+}
@@ -0,0 +1,147 @@
+package webdav
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func (d *Driver) newClient() {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: d.SkipVerify}
+	d.client = &http.Client{Transport: transport}
+}
+
+// getURL convert the actual path to the url on the server
+func (d *Driver) getURL(path string) string {
+	u := *d.address
+	u.Path = stdpath.Join(d.address.Path, path)
+	return u.String()
+}
+
+// getPath convert the href in the response of PROPFIND to the actual path
+func (d *Driver) getPath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return utils.StandardizePath(strings.TrimPrefix(u.Path, strings.TrimSuffix(d.address.Path, "/"))), nil
+}
+
+func (d *Driver) authHeader() http.Header {
+	header := http.Header{}
+	if d.Username != "" || d.Password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(d.Username + ":" + d.Password))
+		header.Set("Authorization", "Basic "+auth)
+	}
+	return header
+}
+
+func (d *Driver) request(ctx context.Context, method, path string, body io.Reader, callback func(req *http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.getURL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range d.authHeader() {
+		req.Header[k] = v
+	}
+	if callback != nil {
+		callback(req)
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	if res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		return nil, errors.WithStack(errs.StatusError{
+			Code: res.StatusCode,
+			Msg:  fmt.Sprintf("%s %s: %s %s", method, path, res.Status, msg),
+		})
+	}
+	return res, nil
+}
+
+// do send a request which doesn't care about the response body
+func (d *Driver) do(ctx context.Context, method, path string, callback func(req *http.Request)) error {
+	res, err := d.request(ctx, method, path, nil, callback)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// propfind return the objects of the path with the given depth,
+// the first one is the path itself
+func (d *Driver) propfind(ctx context.Context, path string, depth string) ([]*model.Object, error) {
+	res, err := d.request(ctx, "PROPFIND", path, strings.NewReader(propfindBody), func(req *http.Request) {
+		req.Header.Set("Depth", depth)
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var ms multistatus
+	if err = xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, errors.Wrap(err, "error while decode multistatus")
+	}
+	var self *model.Object
+	objs := make([]*model.Object, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		p, err := d.getPath(r.Href)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid href %s", r.Href)
+		}
+		obj := responseToObj(r, p)
+		if obj == nil {
+			continue
+		}
+		if utils.PathEqual(p, path) {
+			self = obj
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	if self == nil {
+		return nil, errors.Errorf("the response of PROPFIND %s doesn't contain itself", path)
+	}
+	return append([]*model.Object{self}, objs...), nil
+}
+
+func responseToObj(r response, path string) *model.Object {
+	for _, ps := range r.Propstats {
+		// such as HTTP/1.1 200 OK
+		if fields := strings.Fields(ps.Status); len(fields) < 2 || fields[1] != "200" {
+			continue
+		}
+		obj := &model.Object{
+			ID:       path,
+			Name:     stdpath.Base(path),
+			IsFolder: ps.Prop.ResourceType.Collection != nil,
+		}
+		obj.Size, _ = strconv.ParseInt(strings.TrimSpace(ps.Prop.ContentLength), 10, 64)
+		obj.Modified, _ = http.ParseTime(strings.TrimSpace(ps.Prop.LastModified))
+		return obj
+	}
+	return nil
+}
@@ -3,16 +3,48 @@ package aria2
 import (
 	"context"
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
 	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"path/filepath"
 )
 
-func AddURI(ctx context.Context, uri string, dstDirPath string) error {
+const downKind = "down"
+
+func init() {
+	DownTaskManager.RegisterResumer(downKind, resumeDown)
+}
+
+// downArgs is the persisted args of download tasks
+type downArgs struct {
+	URI        string `json:"uri"`
+	DstDirPath string `json:"dst_dir_path"`
+	TempDir    string `json:"temp_dir"`
+	// Tool is the name of the offline download tool, it's aria2 if empty
+	Tool     string `json:"tool,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// DownOptions choose the tool of the download and the checksum to verify, both are optional
+type DownOptions struct {
+	Tool     string
+	Checksum string
+}
+
+func AddURI(ctx context.Context, uri string, dstDirPath string, opts DownOptions) error {
+	tool, err := GetTool(opts.Tool)
+	if err != nil {
+		return err
+	}
+	if !tool.IsReady() {
+		return errors.Errorf("%s is not ready", tool.Name())
+	}
 	// check account
 	account, dstDirActualPath, err := operations.GetAccountAndActualPath(dstDirPath)
 	if err != nil {
@@ -34,27 +66,87 @@ func AddURI(ctx context.Context, uri string, dstDirPath string) error {
 			return errors.WithStack(errs.NotFolder)
 		}
 	}
-	// call aria2 rpc
+	// add to the tool
 	tempDir := filepath.Join(conf.Conf.TempDir, "aria2", uuid.NewString())
-	options := map[string]interface{}{
-		"dir": tempDir,
-	}
-	gid, err := client.AddURI([]string{uri}, options)
+	gid, err := tool.AddURI(uri, tempDir, AddOptions{
+		Checksum: opts.Checksum,
+		Limiters: bandwidth.UserDownLimiters(bandwidth.UserOf(ctx)),
+	})
 	if err != nil {
-		return errors.Wrapf(err, "failed to add uri %s", uri)
+		return errors.WithMessagef(err, "failed to add uri %s", uri)
+	}
+	args, _ := utils.Json.MarshalToString(downArgs{
+		URI:        uri,
+		DstDirPath: dstDirPath,
+		TempDir:    tempDir,
+		Tool:       tool.Name(),
+		Checksum:   opts.Checksum,
+	})
+	var creator string
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		creator = user.Username
 	}
 	DownTaskManager.Submit(task.WithCancelCtx(&task.Task[string]{
-		ID:   gid,
-		Name: fmt.Sprintf("download %s to [%s](%s)", uri, account.GetAccount().VirtualPath, dstDirActualPath),
-		Func: func(tsk *task.Task[string]) error {
-			m := &Monitor{
-				tsk:        tsk,
-				tempDir:    tempDir,
-				retried:    0,
-				dstDirPath: dstDirPath,
-			}
-			return m.Loop()
-		},
+		ID:      gid,
+		Name:    fmt.Sprintf("download %s to [%s](%s)", uri, account.GetAccount().VirtualPath, dstDirActualPath),
+		Kind:    downKind,
+		Args:    args,
+		Func:    downFunc(tool, tempDir, dstDirPath),
+		Creator: creator,
 	}))
 	return nil
 }
+
+func downFunc(tool Tool, tempDir, dstDirPath string) task.Func[string] {
+	return func(tsk *task.Task[string]) error {
+		m := &Monitor{
+			tsk:        tsk,
+			tool:       tool,
+			tempDir:    tempDir,
+			retried:    0,
+			dstDirPath: dstDirPath,
+		}
+		return m.Loop()
+	}
+}
+
+// resumeDown monitor the download again, the uri is added again if the tool doesn't know it
+func resumeDown(tsk *task.Task[string]) error {
+	var args downArgs
+	if err := utils.Json.UnmarshalFromString(tsk.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	// the files are being transferred by transfer tasks, which are resumed by themselves
+	if tsk.GetStatus() == statusTransferring {
+		tsk.Func = func(tsk *task.Task[string]) error {
+			tsk.SetStatus("completed")
+			return nil
+		}
+		return nil
+	}
+	if args.Tool == "" {
+		args.Tool = defaultTool
+	}
+	tool, err := GetTool(args.Tool)
+	if err != nil {
+		return err
+	}
+	if !tool.IsReady() {
+		return errors.Errorf("%s is not ready", tool.Name())
+	}
+	if _, err := tool.Status(tsk.ID); err != nil {
+		// add it paused, it's unpaused when the task is resumed
+		gid, err := tool.AddURI(args.URI, args.TempDir, AddOptions{
+			Paused:   tsk.GetState() == task.PAUSED,
+			Checksum: args.Checksum,
+			// the creator is persisted, so the download is limited as before
+			Limiters: bandwidth.UserDownLimiters(bandwidth.UserOf(tsk.Ctx)),
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "failed to add uri %s", args.URI)
+		}
+		tsk.ID = gid
+	}
+	tsk.Func = downFunc(tool, args.TempDir, args.DstDirPath)
+	return nil
+}
@@ -49,7 +49,7 @@ func TestDown(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create account: %+v", err)
 	}
-	err = AddURI(context.Background(), "https://nodejs.org/dist/index.json", "/test")
+	err = AddURI(context.Background(), "https://nodejs.org/dist/index.json", "/test", DownOptions{})
 	if err != nil {
 		t.Errorf("failed to add uri: %+v", err)
 	}
@@ -0,0 +1,196 @@
+package aria2
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/pkg/download"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const httpToolName = "http"
+
+func init() {
+	RegisterTool(&httpTool{
+		downloader: &download.Downloader{
+			Limiters: []*ratelimit.Limiter{bandwidth.GlobalDown()},
+		},
+	})
+}
+
+// httpTool is the native downloader for the hosts without aria2, the downloads are kept
+// in memory, the stopped ones are resumed from the files in their dirs after restarted
+type httpTool struct {
+	downloader *download.Downloader
+	jobs       generic_sync.MapOf[string, *httpJob]
+}
+
+type httpJob struct {
+	uri, dir, checksum string
+	limiters           []*ratelimit.Limiter
+
+	mu       sync.Mutex
+	status   Status
+	filePath string
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func (h *httpTool) Name() string {
+	return httpToolName
+}
+
+func (h *httpTool) IsReady() bool {
+	return true
+}
+
+func (h *httpTool) AddURI(uri, dir string, opts AddOptions) (string, error) {
+	gid := uuid.NewString()
+	job := &httpJob{
+		uri:      uri,
+		dir:      dir,
+		checksum: opts.Checksum,
+		limiters: opts.Limiters,
+		status:   Status{Status: "paused"},
+	}
+	h.jobs.Store(gid, job)
+	if !opts.Paused {
+		h.start(gid, job)
+	}
+	return gid, nil
+}
+
+// start download in background, it continues from the part downloaded before
+func (h *httpTool) start(gid string, job *httpJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job.mu.Lock()
+	job.status.Status = "active"
+	job.cancel = cancel
+	job.done = make(chan struct{})
+	done := job.done
+	job.mu.Unlock()
+	downloader := h.downloader
+	if len(job.limiters) > 0 {
+		d := *h.downloader
+		d.Limiters = job.limiters
+		downloader = &d
+	}
+	go func() {
+		defer close(done)
+		filePath, err := downloader.Download(ctx, job.uri, job.dir, job.checksum, func(completed, total int64) {
+			job.mu.Lock()
+			job.status.CompletedLength, job.status.TotalLength = completed, total
+			job.mu.Unlock()
+		})
+		job.mu.Lock()
+		switch {
+		case ctx.Err() != nil:
+			// paused or removed
+		case err != nil:
+			job.status.Status = "error"
+			job.status.ErrorMessage = err.Error()
+		default:
+			job.status.Status = "complete"
+			job.filePath = filePath
+			if fi, err := os.Stat(filePath); err == nil {
+				job.status.TotalLength, job.status.CompletedLength = fi.Size(), fi.Size()
+			}
+		}
+		job.mu.Unlock()
+		h.signal(gid)
+	}()
+}
+
+// stop cancel the download and wait for it to stop
+func (h *httpTool) stop(job *httpJob, status string) {
+	job.mu.Lock()
+	cancel, done := job.cancel, job.done
+	if job.status.Status == "active" {
+		job.status.Status = status
+	}
+	job.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// signal notify the monitor of the download without blocking the download
+func (h *httpTool) signal(gid string) {
+	if signal, ok := notify.Signals.Load(gid); ok {
+		select {
+		case signal <- Completed:
+		default:
+		}
+	}
+}
+
+func (h *httpTool) job(gid string) (*httpJob, error) {
+	job, ok := h.jobs.Load(gid)
+	if !ok {
+		return nil, errors.Errorf("no download with gid %s", gid)
+	}
+	return job, nil
+}
+
+func (h *httpTool) Status(gid string) (*Status, error) {
+	job, err := h.job(gid)
+	if err != nil {
+		return nil, err
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	status := job.status
+	return &status, nil
+}
+
+func (h *httpTool) Pause(gid string) error {
+	job, err := h.job(gid)
+	if err != nil {
+		return err
+	}
+	h.stop(job, "paused")
+	return nil
+}
+
+func (h *httpTool) Unpause(gid string) error {
+	job, err := h.job(gid)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	paused := job.status.Status == "paused"
+	job.mu.Unlock()
+	if paused {
+		h.start(gid, job)
+	}
+	return nil
+}
+
+func (h *httpTool) Remove(gid string) error {
+	job, err := h.job(gid)
+	if err != nil {
+		return err
+	}
+	h.stop(job, "removed")
+	h.jobs.Delete(gid)
+	return nil
+}
+
+func (h *httpTool) Files(gid string) ([]File, error) {
+	job, err := h.job(gid)
+	if err != nil {
+		return nil, err
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if job.filePath == "" {
+		return nil, errors.Errorf("download %s is not complete", gid)
+	}
+	return []File{{Path: job.filePath, Length: job.status.TotalLength}}, nil
+}
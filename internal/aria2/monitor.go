@@ -2,27 +2,28 @@ package aria2
 
 import (
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
 	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"mime"
 	"os"
 	"path"
-	"strconv"
-	"sync"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 )
 
 type Monitor struct {
 	tsk        *task.Task[string]
+	tool       Tool
 	tempDir    string
 	retried    int
 	c          chan int
 	dstDirPath string
-	finish     chan struct{}
 }
 
 func (m *Monitor) Loop() error {
@@ -32,8 +33,13 @@ func (m *Monitor) Loop() error {
 		//_ = os.RemoveAll(m.tempDir)
 	}()
 	m.c = make(chan int)
-	m.finish = make(chan struct{})
 	notify.Signals.Store(m.tsk.ID, m.c)
+	// it was paused by the task
+	if info, err := m.tool.Status(m.tsk.ID); err == nil && info.Status == "paused" {
+		if err := m.tool.Unpause(m.tsk.ID); err != nil {
+			return errors.WithMessagef(err, "failed to unpause %s", m.tsk.ID)
+		}
+	}
 	var (
 		err error
 		ok  bool
@@ -42,8 +48,11 @@ outer:
 	for {
 		select {
 		case <-m.tsk.Ctx.Done():
-			_, err := client.Remove(m.tsk.ID)
-			return err
+			if m.tsk.Pausing() {
+				// keep the downloaded part in the tool
+				return m.tool.Pause(m.tsk.ID)
+			}
+			return m.tool.Remove(m.tsk.ID)
 		case <-m.c:
 			ok, err = m.Update()
 			if ok {
@@ -59,19 +68,18 @@ outer:
 	if err != nil {
 		return err
 	}
-	m.tsk.SetStatus("aria2 download completed, transferring")
-	<-m.finish
 	m.tsk.SetStatus("completed")
 	return nil
 }
 
 func (m *Monitor) Update() (bool, error) {
-	info, err := client.TellStatus(m.tsk.ID)
+	info, err := m.tool.Status(m.tsk.ID)
 	if err != nil {
 		m.retried++
-	}
-	if m.retried > 5 {
-		return true, errors.Errorf("failed to get status of %s, retried %d times", m.tsk.ID, m.retried)
+		if m.retried > 5 {
+			return true, errors.WithMessagef(err, "failed to get status of %s, retried %d times", m.tsk.ID, m.retried)
+		}
+		return false, nil
 	}
 	m.retried = 0
 	if len(info.FollowedBy) != 0 {
@@ -81,16 +89,9 @@ func (m *Monitor) Update() (bool, error) {
 		notify.Signals.Store(gid, m.c)
 	}
 	// update download status
-	total, err := strconv.ParseUint(info.TotalLength, 10, 64)
-	if err != nil {
-		total = 0
+	if info.TotalLength > 0 {
+		m.tsk.SetProgress(int(float64(info.CompletedLength) / float64(info.TotalLength) * 100))
 	}
-	downloaded, err := strconv.ParseUint(info.CompletedLength, 10, 64)
-	if err != nil {
-		downloaded = 0
-	}
-	progress := float64(downloaded) / float64(total) * 100
-	m.tsk.SetProgress(int(progress))
 	switch info.Status {
 	case "complete":
 		err := m.Complete()
@@ -98,7 +99,7 @@ func (m *Monitor) Update() (bool, error) {
 	case "error":
 		return true, errors.Errorf("failed to download %s, error: %s", m.tsk.ID, info.ErrorMessage)
 	case "active", "waiting", "paused":
-		m.tsk.SetStatus("aria2: " + info.Status)
+		m.tsk.SetStatus(m.tool.Name() + ": " + info.Status)
 		return false, nil
 	case "removed":
 		return true, errors.Errorf("failed to download %s, removed", m.tsk.ID)
@@ -111,6 +112,24 @@ var TransferTaskManager = task.NewTaskManager[uint64](3, func(k *uint64) {
 	atomic.AddUint64(k, 1)
 })
 
+// the status of download task while its files are being submitted to transfer
+const statusTransferring = "aria2 download completed, transferring"
+
+const transferKind = "transfer"
+
+func init() {
+	TransferTaskManager.SetRetryPolicy(task.DefaultRetryPolicy)
+	TransferTaskManager.RegisterResumer(transferKind, resumeTransfer)
+}
+
+// transferArgs is the persisted args of transfer tasks
+type transferArgs struct {
+	DstDirPath string `json:"dst_dir_path"`
+	FilePath   string `json:"file_path"`
+	Size       int64  `json:"size"`
+	TempDir    string `json:"temp_dir"`
+}
+
 func (m *Monitor) Complete() error {
 	// check dstDir again
 	account, dstDirActualPath, err := operations.GetAccountAndActualPath(m.dstDirPath)
@@ -118,48 +137,121 @@ func (m *Monitor) Complete() error {
 		return errors.WithMessage(err, "failed get account")
 	}
 	// get files
-	files, err := client.GetFiles(m.tsk.ID)
+	files, err := m.tool.Files(m.tsk.ID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get files of %s", m.tsk.ID)
-	}
-	// upload files
-	var wg sync.WaitGroup
-	wg.Add(len(files))
-	go func() {
-		wg.Wait()
-		err := os.RemoveAll(m.tempDir)
-		m.finish <- struct{}{}
-		if err != nil {
-			log.Errorf("failed to remove aria2 temp dir: %+v", err.Error())
-		}
-	}()
+		return errors.WithMessagef(err, "failed to get files of %s", m.tsk.ID)
+	}
+	m.tsk.SetStatus(statusTransferring)
+	// upload files, the temp dir is removed by the last transfer task
 	for _, file := range files {
+		args, _ := utils.Json.MarshalToString(transferArgs{
+			DstDirPath: m.dstDirPath,
+			FilePath:   file.Path,
+			Size:       file.Length,
+			TempDir:    m.tempDir,
+		})
 		TransferTaskManager.Submit(task.WithCancelCtx[uint64](&task.Task[uint64]{
-			Name: fmt.Sprintf("transfer %s to [%s](%s)", file.Path, account.GetAccount().VirtualPath, dstDirActualPath),
-			Func: func(tsk *task.Task[uint64]) error {
-				defer wg.Done()
-				size, _ := strconv.ParseInt(file.Length, 10, 64)
-				mimetype := mime.TypeByExtension(path.Ext(file.Path))
-				if mimetype == "" {
-					mimetype = "application/octet-stream"
-				}
-				f, err := os.Open(file.Path)
-				if err != nil {
-					return errors.Wrapf(err, "failed to open file %s", file.Path)
-				}
-				stream := &model.FileStream{
-					Obj: model.Object{
-						Name:     path.Base(file.Path),
-						Size:     size,
-						Modified: time.Now(),
-						IsFolder: false,
-					},
-					ReadCloser: f,
-					Mimetype:   mimetype,
-				}
-				return operations.Put(tsk.Ctx, account, dstDirActualPath, stream, tsk.SetProgress)
-			},
+			Name:    fmt.Sprintf("transfer %s to [%s](%s)", file.Path, account.GetAccount().VirtualPath, dstDirActualPath),
+			Kind:    transferKind,
+			Args:    args,
+			Func:    transferFunc(account, dstDirActualPath, file.Path, file.Length, m.tempDir),
+			Creator: m.tsk.Creator,
 		}))
 	}
+	// the transfer tasks own the files now, the torrent can be removed
+	if r, ok := m.tool.(Releaser); ok {
+		if err := r.Release(m.tsk.ID); err != nil {
+			log.Errorf("failed to release %s: %+v", m.tsk.ID, err)
+		}
+	}
+	return nil
+}
+
+// transferFunc upload the downloaded file, which is removed by operations.Put,
+// then verify the uploaded file and remove the temp dir if it's empty
+func transferFunc(account driver.Driver, dstDirActualPath, filePath string, size int64, tempDir string) task.Func[uint64] {
+	return func(tsk *task.Task[uint64]) error {
+		mimetype := mime.TypeByExtension(path.Ext(filePath))
+		if mimetype == "" {
+			mimetype = "application/octet-stream"
+		}
+		// the file is removed after put, so compute the hashes to verify before,
+		// only the size is verified if the account can't report the hashes
+		hashes, err := operations.FileHashes(nil, filePath, operations.DstHashTypes(account)...)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(filePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open file %s", filePath)
+		}
+		stream := &model.FileStream{
+			Obj: model.Object{
+				Name:     path.Base(filePath),
+				Size:     size,
+				Modified: time.Now(),
+				IsFolder: false,
+			},
+			ReadCloser: f,
+			Mimetype:   mimetype,
+		}
+		err = operations.Put(tsk.Ctx, account, dstDirActualPath, stream, tsk.SetProgress)
+		if err != nil {
+			return err
+		}
+		err = operations.Verify(tsk.Ctx, account, path.Join(dstDirActualPath, stream.GetName()), size, hashes)
+		if err != nil {
+			return err
+		}
+		if !hasFiles(tempDir) {
+			if err := os.RemoveAll(tempDir); err != nil {
+				log.Errorf("failed to remove aria2 temp dir: %+v", err)
+			}
+		}
+		return nil
+	}
+}
+
+// resumeTransfer transfer the downloaded file again
+func resumeTransfer(tsk *task.Task[uint64]) error {
+	var args transferArgs
+	if err := utils.Json.UnmarshalFromString(tsk.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	account, dstDirActualPath, err := operations.GetAccountAndActualPath(args.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get account")
+	}
+	tsk.Func = transferFunc(account, dstDirActualPath, args.FilePath, args.Size, args.TempDir)
 	return nil
 }
+
+// hasFiles check if there are regular files in the dir
+func hasFiles(dir string) bool {
+	errFound := errors.New("found")
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			return errFound
+		}
+		return nil
+	})
+	return err == errFound
+}
+
+// TempDirsInUse return the temp dirs of undone download and transfer tasks, which shouldn't be cleared
+func TempDirsInUse() []string {
+	var dirs []string
+	for _, t := range DownTaskManager.ListUndone() {
+		var args downArgs
+		if err := utils.Json.UnmarshalFromString(t.Args, &args); err == nil && args.TempDir != "" {
+			dirs = append(dirs, args.TempDir)
+		}
+	}
+	for _, t := range TransferTaskManager.ListUndone() {
+		var args transferArgs
+		if err := utils.Json.UnmarshalFromString(t.Args, &args); err == nil && args.TempDir != "" {
+			dirs = append(dirs, args.TempDir)
+		}
+	}
+	return dirs
+}
@@ -1,6 +1,7 @@
 package aria2
 
 import (
+	"github.com/alist-org/alist/v3/internal/message"
 	"github.com/alist-org/alist/v3/pkg/aria2/rpc"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 )
@@ -23,6 +24,7 @@ func NewNotify() *Notify {
 
 func (n *Notify) OnDownloadStart(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "downloading")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Downloading
 		}
@@ -31,6 +33,7 @@ func (n *Notify) OnDownloadStart(events []rpc.Event) {
 
 func (n *Notify) OnDownloadPause(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "paused")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Paused
 		}
@@ -39,6 +42,7 @@ func (n *Notify) OnDownloadPause(events []rpc.Event) {
 
 func (n *Notify) OnDownloadStop(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "stopped")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Stopped
 		}
@@ -47,6 +51,7 @@ func (n *Notify) OnDownloadStop(events []rpc.Event) {
 
 func (n *Notify) OnDownloadComplete(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "completed")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Completed
 		}
@@ -55,6 +60,7 @@ func (n *Notify) OnDownloadComplete(events []rpc.Event) {
 
 func (n *Notify) OnDownloadError(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "errored")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Errored
 		}
@@ -63,8 +69,18 @@ func (n *Notify) OnDownloadError(events []rpc.Event) {
 
 func (n *Notify) OnBtDownloadComplete(events []rpc.Event) {
 	for _, e := range events {
+		publish(e.Gid, "completed")
 		if signal, ok := n.Signals.Load(e.Gid); ok {
 			signal <- Completed
 		}
 	}
 }
+
+// publish the event of aria2 to the creator of the download task
+func publish(gid, status string) {
+	user := ""
+	if tsk, ok := DownTaskManager.Get(gid); ok {
+		user = tsk.Creator
+	}
+	message.PublishAria2(gid, status, user)
+}
@@ -0,0 +1,174 @@
+package aria2
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/qbittorrent"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const qbittorrentToolName = "qbittorrent"
+
+var qbitTool = &qbittorrentTool{}
+
+func init() {
+	RegisterTool(qbitTool)
+}
+
+// InitQbittorrentClient log in to qBittorrent, it's not used if the url is empty
+func InitQbittorrentClient() error {
+	uri := setting.GetByKey(conf.QbittorrentUrl)
+	if uri == "" {
+		qbitTool.setClient(nil)
+		return nil
+	}
+	c, err := qbittorrent.New(uri)
+	if err != nil {
+		return errors.WithMessage(err, "failed to init qbittorrent client")
+	}
+	version, err := c.Version()
+	if err != nil {
+		return errors.WithMessage(err, "failed get qbittorrent version")
+	}
+	qbitTool.setClient(c)
+	log.Infof("using qbittorrent version: %s", version)
+	return nil
+}
+
+// qbittorrentTool download by qBittorrent, the gid is the tag of the torrent
+type qbittorrentTool struct {
+	// mu guards client, which is replaced when the settings are changed
+	mu     sync.RWMutex
+	client *qbittorrent.Client
+}
+
+func (t *qbittorrentTool) setClient(c *qbittorrent.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.client = c
+}
+
+func (t *qbittorrentTool) getClient() (*qbittorrent.Client, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.client == nil {
+		return nil, errors.New("qbittorrent is not ready")
+	}
+	return t.client, nil
+}
+
+func (t *qbittorrentTool) Name() string {
+	return qbittorrentToolName
+}
+
+func (t *qbittorrentTool) IsReady() bool {
+	_, err := t.getClient()
+	return err == nil
+}
+
+func (t *qbittorrentTool) AddURI(uri, dir string, opts AddOptions) (string, error) {
+	if opts.Checksum != "" {
+		return "", errors.New("qbittorrent doesn't support checksum")
+	}
+	c, err := t.getClient()
+	if err != nil {
+		return "", err
+	}
+	tag := uuid.NewString()
+	if err := c.AddURL(uri, dir, tag, opts.Paused); err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+// info return the client and the info of the torrent with the tag gid
+func (t *qbittorrentTool) info(gid string) (*qbittorrent.Client, *qbittorrent.TorrentInfo, error) {
+	c, err := t.getClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := c.GetInfo(gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, info, nil
+}
+
+func (t *qbittorrentTool) Status(gid string) (*Status, error) {
+	_, info, err := t.info(gid)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{TotalLength: info.Size, CompletedLength: info.Completed}
+	switch {
+	case info.Progress >= 1 || utils.SliceContains([]string{"uploading", "pausedUP", "stoppedUP", "queuedUP", "stalledUP", "checkingUP", "forcedUP"}, info.State):
+		status.Status = "complete"
+	case info.State == "error" || info.State == "missingFiles":
+		status.Status = "error"
+		status.ErrorMessage = info.State
+	case info.State == "pausedDL" || info.State == "stoppedDL":
+		status.Status = "paused"
+	case utils.SliceContains([]string{"queuedDL", "checkingDL", "metaDL", "allocating", "checkingResumeData"}, info.State):
+		status.Status = "waiting"
+	default:
+		status.Status = "active"
+	}
+	return status, nil
+}
+
+func (t *qbittorrentTool) Pause(gid string) error {
+	c, info, err := t.info(gid)
+	if err != nil {
+		return err
+	}
+	return c.Pause(info.Hash)
+}
+
+func (t *qbittorrentTool) Unpause(gid string) error {
+	c, info, err := t.info(gid)
+	if err != nil {
+		return err
+	}
+	return c.Resume(info.Hash)
+}
+
+func (t *qbittorrentTool) Remove(gid string) error {
+	c, info, err := t.info(gid)
+	if err != nil {
+		return err
+	}
+	return c.Delete(info.Hash, true)
+}
+
+// Files return the files in the save path
+func (t *qbittorrentTool) Files(gid string) ([]File, error) {
+	c, info, err := t.info(gid)
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.GetFiles(info.Hash)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]File, 0, len(files))
+	for _, file := range files {
+		res = append(res, File{Path: filepath.Join(info.SavePath, file.Name), Length: file.Size})
+	}
+	return res, nil
+}
+
+// Release remove the torrent from qBittorrent with the files kept,
+// so it doesn't seed the files being transferred
+func (t *qbittorrentTool) Release(gid string) error {
+	c, info, err := t.info(gid)
+	if err != nil {
+		return err
+	}
+	return c.Delete(info.Hash, false)
+}
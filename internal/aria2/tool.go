@@ -0,0 +1,155 @@
+package aria2
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
+	"github.com/pkg/errors"
+)
+
+// Tool is an offline download tool, the status and files of a download are queried by its gid
+type Tool interface {
+	Name() string
+	IsReady() bool
+	// AddURI start downloading the uri into dir and return its gid
+	AddURI(uri, dir string, opts AddOptions) (string, error)
+	Status(gid string) (*Status, error)
+	Pause(gid string) error
+	Unpause(gid string) error
+	Remove(gid string) error
+	// Files return the downloaded files, it's called after the download is complete
+	Files(gid string) ([]File, error)
+}
+
+// Releaser is implemented by the tools which keep the complete downloads, such as the seeding
+// torrents. Release is called after the files are handed to the transfer tasks
+type Releaser interface {
+	Release(gid string) error
+}
+
+type AddOptions struct {
+	// Paused add the download without starting it
+	Paused bool
+	// Checksum is in the form of algo=hex, such as sha-256=...
+	Checksum string
+	// Limiters limit the bandwidth of the download, the tools which limit by themselves ignore them
+	Limiters []*ratelimit.Limiter
+}
+
+// Status of a download, the status is one of active, waiting, paused, complete, error and removed
+type Status struct {
+	Status          string
+	TotalLength     int64
+	CompletedLength int64
+	// FollowedBy is the gids of the downloads started by it, such as the torrent by its metadata
+	FollowedBy   []string
+	ErrorMessage string
+}
+
+type File struct {
+	Path   string
+	Length int64
+}
+
+const defaultTool = "aria2"
+
+var tools generic_sync.MapOf[string, Tool]
+
+func RegisterTool(tool Tool) {
+	tools.Store(tool.Name(), tool)
+}
+
+// GetTool return the tool by name, the empty name is the default tool
+func GetTool(name string) (Tool, error) {
+	if name == "" {
+		return DefaultTool(), nil
+	}
+	tool, ok := tools.Load(name)
+	if !ok {
+		return nil, errors.Errorf("no offline download tool named %s", name)
+	}
+	return tool, nil
+}
+
+// DefaultTool return aria2 if it's ready, otherwise the native http downloader
+func DefaultTool() Tool {
+	if tool, ok := tools.Load(defaultTool); ok && tool.IsReady() {
+		return tool
+	}
+	tool, _ := tools.Load(httpToolName)
+	return tool
+}
+
+func init() {
+	RegisterTool(aria2Tool{})
+}
+
+// aria2Tool download by the aria2 rpc client
+type aria2Tool struct{}
+
+func (aria2Tool) Name() string {
+	return defaultTool
+}
+
+func (aria2Tool) IsReady() bool {
+	return IsAria2Ready()
+}
+
+func (aria2Tool) AddURI(uri, dir string, opts AddOptions) (string, error) {
+	options := map[string]interface{}{
+		"dir": dir,
+	}
+	if opts.Paused {
+		options["pause"] = "true"
+	}
+	if opts.Checksum != "" {
+		options["checksum"] = opts.Checksum
+	}
+	gid, err := client.AddURI([]string{uri}, options)
+	return gid, errors.WithStack(err)
+}
+
+func (aria2Tool) Status(gid string) (*Status, error) {
+	info, err := client.TellStatus(gid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	total, _ := strconv.ParseInt(info.TotalLength, 10, 64)
+	completed, _ := strconv.ParseInt(info.CompletedLength, 10, 64)
+	return &Status{
+		Status:          info.Status,
+		TotalLength:     total,
+		CompletedLength: completed,
+		FollowedBy:      info.FollowedBy,
+		ErrorMessage:    info.ErrorMessage,
+	}, nil
+}
+
+func (aria2Tool) Pause(gid string) error {
+	_, err := client.Pause(gid)
+	return errors.WithStack(err)
+}
+
+func (aria2Tool) Unpause(gid string) error {
+	_, err := client.Unpause(gid)
+	return errors.WithStack(err)
+}
+
+func (aria2Tool) Remove(gid string) error {
+	_, err := client.Remove(gid)
+	return errors.WithStack(err)
+}
+
+func (aria2Tool) Files(gid string) ([]File, error) {
+	files, err := client.GetFiles(gid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res := make([]File, 0, len(files))
+	for _, file := range files {
+		length, _ := strconv.ParseInt(file.Length, 10, 64)
+		res = append(res, File{Path: file.Path, Length: length})
+	}
+	return res, nil
+}
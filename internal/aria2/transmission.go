@@ -0,0 +1,160 @@
+package aria2
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/transmission"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const transmissionToolName = "transmission"
+
+var trTool = &transmissionTool{}
+
+func init() {
+	RegisterTool(trTool)
+}
+
+// InitTransmissionClient connect to Transmission, it's not used if the url is empty
+func InitTransmissionClient() error {
+	uri := setting.GetByKey(conf.TransmissionUrl)
+	if uri == "" {
+		trTool.setClient(nil)
+		return nil
+	}
+	c, err := transmission.New(uri)
+	if err != nil {
+		return errors.WithMessage(err, "failed to init transmission client")
+	}
+	version, err := c.Version()
+	if err != nil {
+		return errors.WithMessage(err, "failed get transmission version")
+	}
+	trTool.setClient(c)
+	log.Infof("using transmission version: %s", version)
+	return nil
+}
+
+// transmissionTool download by Transmission, the gid is the hash of the torrent
+type transmissionTool struct {
+	// mu guards client, which is replaced when the settings are changed
+	mu     sync.RWMutex
+	client *transmission.Client
+}
+
+func (t *transmissionTool) setClient(c *transmission.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.client = c
+}
+
+func (t *transmissionTool) getClient() (*transmission.Client, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.client == nil {
+		return nil, errors.New("transmission is not ready")
+	}
+	return t.client, nil
+}
+
+func (t *transmissionTool) Name() string {
+	return transmissionToolName
+}
+
+func (t *transmissionTool) IsReady() bool {
+	_, err := t.getClient()
+	return err == nil
+}
+
+func (t *transmissionTool) AddURI(uri, dir string, opts AddOptions) (string, error) {
+	if opts.Checksum != "" {
+		return "", errors.New("transmission doesn't support checksum")
+	}
+	c, err := t.getClient()
+	if err != nil {
+		return "", err
+	}
+	return c.Add(uri, dir, opts.Paused)
+}
+
+func (t *transmissionTool) Status(gid string) (*Status, error) {
+	c, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	tr, err := c.Get(gid)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{TotalLength: tr.SizeWhenDone, CompletedLength: tr.SizeWhenDone - tr.LeftUntilDone}
+	switch {
+	case tr.Error != 0:
+		status.Status = "error"
+		status.ErrorMessage = tr.ErrorString
+	// the size is unknown before the metadata of magnet is downloaded
+	case tr.SizeWhenDone > 0 && tr.LeftUntilDone == 0:
+		status.Status = "complete"
+	case tr.Status == transmission.StatusStopped:
+		status.Status = "paused"
+	case tr.Status == transmission.StatusDownload || tr.Status == transmission.StatusSeed:
+		status.Status = "active"
+	default:
+		status.Status = "waiting"
+	}
+	return status, nil
+}
+
+func (t *transmissionTool) Pause(gid string) error {
+	c, err := t.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Stop(gid)
+}
+
+func (t *transmissionTool) Unpause(gid string) error {
+	c, err := t.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Start(gid)
+}
+
+func (t *transmissionTool) Remove(gid string) error {
+	c, err := t.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Remove(gid, true)
+}
+
+// Files return the files in the download dir
+func (t *transmissionTool) Files(gid string) ([]File, error) {
+	c, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	tr, err := c.Get(gid)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]File, 0, len(tr.Files))
+	for _, file := range tr.Files {
+		res = append(res, File{Path: filepath.Join(tr.DownloadDir, file.Name), Length: file.Length})
+	}
+	return res, nil
+}
+
+// Release remove the torrent from Transmission with the files kept,
+// so it doesn't seed the files being transferred
+func (t *transmissionTool) Release(gid string) error {
+	c, err := t.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Remove(gid, false)
+}
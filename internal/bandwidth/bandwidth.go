@@ -0,0 +1,115 @@
+// Package bandwidth keep the rate limiters of the global, accounts and users.
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+const kib = 1024
+
+var (
+	globalDown = ratelimit.New(0)
+	globalUp   = ratelimit.New(0)
+
+	accountDown generic_sync.MapOf[uint, *ratelimit.Limiter]
+	accountUp   generic_sync.MapOf[uint, *ratelimit.Limiter]
+	userDown    generic_sync.MapOf[uint, *ratelimit.Limiter]
+	userUp      generic_sync.MapOf[uint, *ratelimit.Limiter]
+)
+
+// SetGlobal change the global limits in KiB/s, they are shared by all transfers
+func SetGlobal(down, up int64) {
+	globalDown.SetRate(down * kib)
+	globalUp.SetRate(up * kib)
+}
+
+// GlobalDown return the global limiter of downloading, it's unlimited while the rate is 0
+func GlobalDown() *ratelimit.Limiter {
+	return globalDown
+}
+
+// limiter return the limiter of the id in m, which is shared by the transfers of it
+// and its rate is updated to the latest
+func limiter(m *generic_sync.MapOf[uint, *ratelimit.Limiter], id uint, limit int64) *ratelimit.Limiter {
+	l, _ := m.LoadOrStore(id, ratelimit.New(limit*kib))
+	l.SetRate(limit * kib)
+	return l
+}
+
+func limiters(global *ratelimit.Limiter, account, user *ratelimit.Limiter) []*ratelimit.Limiter {
+	var ls []*ratelimit.Limiter
+	for _, l := range []*ratelimit.Limiter{global, account, user} {
+		if l != nil && l.Rate() > 0 {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+// DownLimiters return the limiters of downloading from the account by the user, the user can be nil
+func DownLimiters(user *model.User, account model.Account) []*ratelimit.Limiter {
+	var u *ratelimit.Limiter
+	if user != nil {
+		u = limiter(&userDown, user.ID, user.DownLimit)
+	}
+	return limiters(globalDown, limiter(&accountDown, account.ID, account.DownLimit), u)
+}
+
+// UserDownLimiters return the limiters of downloading by the user from outside of the accounts,
+// such as the offline downloads, the user can be nil
+func UserDownLimiters(user *model.User) []*ratelimit.Limiter {
+	var u *ratelimit.Limiter
+	if user != nil {
+		u = limiter(&userDown, user.ID, user.DownLimit)
+	}
+	return limiters(globalDown, nil, u)
+}
+
+// UpLimiters return the limiters of uploading to the account by the user, the user can be nil
+func UpLimiters(user *model.User, account model.Account) []*ratelimit.Limiter {
+	var u *ratelimit.Limiter
+	if user != nil {
+		u = limiter(&userUp, user.ID, user.UpLimit)
+	}
+	return limiters(globalUp, limiter(&accountUp, account.ID, account.UpLimit), u)
+}
+
+// UserOf return the user in ctx, or the creator of the background task running with ctx,
+// it's nil if neither is found
+func UserOf(ctx context.Context) *model.User {
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		return user
+	}
+	if creator := task.CreatorOf(ctx); creator != "" {
+		if user, err := db.GetUserByName(creator); err == nil {
+			return user
+		}
+	}
+	return nil
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	w io.Writer
+}
+
+func (r responseWriter) Write(p []byte) (int, error) {
+	return r.w.Write(p)
+}
+
+// LimitResponse limit the response of downloading from the account by the user
+func LimitResponse(ctx context.Context, w http.ResponseWriter, user *model.User, account model.Account) http.ResponseWriter {
+	ls := DownLimiters(user, account)
+	if len(ls) == 0 {
+		return w
+	}
+	return responseWriter{ResponseWriter: w, w: ratelimit.NewWriter(ctx, w, ls...)}
+}
@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	log "github.com/sirupsen/logrus"
+)
+
+// accountInitTimeout is the max time to wait for a single account to init
+var accountInitTimeout = time.Minute
+
+// LoadAccounts restore all accounts saved in database,
+// an account failed to init will not abort the others, the error is recorded in its status
+func LoadAccounts() {
+	accounts, err := db.GetAllAccounts()
+	if err != nil {
+		log.Fatalf("failed get accounts: %+v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(accounts))
+	for i := range accounts {
+		go func(account model.Account) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), accountInitTimeout)
+			defer cancel()
+			err := operations.LoadAccount(ctx, account)
+			if err != nil {
+				log.Errorf("failed load account [%s]: %+v", account.VirtualPath, err)
+				return
+			}
+			log.Infof("success load account: [%s], driver: [%s]", account.VirtualPath, account.Driver)
+		}(accounts[i])
+	}
+	wg.Wait()
+}
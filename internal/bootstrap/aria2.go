@@ -8,6 +8,19 @@ import (
 func InitAria2() {
 	go func() {
 		err := aria2.InitClient(2)
-		log.Errorf("failed to init aria2 client: %+v", err)
+		if err != nil {
+			log.Errorf("failed to init aria2 client: %+v", err)
+		}
+		if err := aria2.InitQbittorrentClient(); err != nil {
+			log.Errorf("failed to init qbittorrent client: %+v", err)
+		}
+		if err := aria2.InitTransmissionClient(); err != nil {
+			log.Errorf("failed to init transmission client: %+v", err)
+		}
+		// the downloads of the other tools are recovered even without aria2
+		if err := aria2.DownTaskManager.Recover(); err != nil {
+			log.Errorf("failed recover down tasks: %+v", err)
+		}
+		clearAria2TempDirs()
 	}()
 }
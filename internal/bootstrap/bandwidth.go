@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/setting"
+)
+
+// InitBandwidth set the global bandwidth limits by the settings, and change them once the settings saved
+func InitBandwidth() {
+	setLimits := func(string) {
+		bandwidth.SetGlobal(int64(setting.GetIntSetting(conf.DownLimit, 0)), int64(setting.GetIntSetting(conf.UpLimit, 0)))
+	}
+	setLimits("")
+	db.RegisterSettingHook(conf.DownLimit, setLimits)
+	db.RegisterSettingHook(conf.UpLimit, setLimits)
+}
@@ -49,20 +49,15 @@ func InitConfig() {
 		confFromEnv()
 	}
 	// convert abs path
-	var absPath string
-	var err error
 	if !filepath.IsAbs(conf2.Conf.TempDir) {
-		absPath, err = filepath.Abs(conf2.Conf.TempDir)
+		absPath, err := filepath.Abs(conf2.Conf.TempDir)
 		if err != nil {
 			log.Fatalf("get abs path error: %s", err.Error())
 		}
+		conf2.Conf.TempDir = absPath
 	}
-	conf2.Conf.TempDir = absPath
-	err = os.RemoveAll(filepath.Join(conf2.Conf.TempDir))
-	if err != nil {
-		log.Errorln("failed delete temp file:", err)
-	}
-	err = os.MkdirAll(conf2.Conf.TempDir, 0700)
+	// the temp files of undone tasks are kept, others are cleared after tasks recovered
+	err := os.MkdirAll(conf2.Conf.TempDir, 0700)
 	if err != nil {
 		log.Fatalf("create temp dir error: %s", err.Error())
 	}
@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/syncjob"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitSyncJobs schedule the sync jobs, it should be called after the copy tasks recovered
+func InitSyncJobs() {
+	if err := syncjob.Load(); err != nil {
+		log.Errorf("failed load sync jobs: %+v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/aria2"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/message"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitTaskManagers persist the tasks in database and resume the undone tasks,
+// the download tasks of aria2 are resumed after the aria2 client is ready
+func InitTaskManagers() {
+	setWorkers(conf.CopyTaskWorkers, fs.CopyTaskManager)
+	setWorkers(conf.UploadTaskWorkers, fs.UploadTaskManager)
+	setWorkers(conf.DecompressTaskWorkers, fs.DecompressTaskManager)
+	setWorkers(conf.DownTaskWorkers, aria2.DownTaskManager)
+	setWorkers(conf.TransferTaskWorkers, aria2.TransferTaskManager)
+	recoverTasks("copy", fs.CopyTaskManager)
+	recoverTasks("upload", fs.UploadTaskManager)
+	recoverTasks("decompress", fs.DecompressTaskManager)
+	recoverTasks("transfer", aria2.TransferTaskManager)
+	aria2.DownTaskManager.SetStore(db.TaskStore{Type: "down"})
+	watchTasks("down", aria2.DownTaskManager)
+	clearTempFiles()
+}
+
+// setWorkers set the number of workers of the manager by the setting, and
+// change it once the setting saved
+func setWorkers[K comparable](key string, tm *task.Manager[K]) {
+	tm.SetWorkers(setting.GetIntSetting(key, tm.GetWorkers()))
+	db.RegisterSettingHook(key, func(value string) {
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("invalid setting [%s]: %s", key, value)
+			return
+		}
+		tm.SetWorkers(workers)
+	})
+}
+
+func recoverTasks[K comparable](typ string, tm *task.Manager[K]) {
+	tm.SetStore(db.TaskStore{Type: typ})
+	watchTasks(typ, tm)
+	if err := tm.Recover(); err != nil {
+		log.Errorf("failed recover %s tasks: %+v", typ, err)
+	}
+}
+
+// watchTasks push the changes of the tasks to the subscribers of the message hub
+func watchTasks[K comparable](typ string, tm *task.Manager[K]) {
+	tm.Watch(func(t *task.Task[K]) {
+		message.PublishTask(typ, t)
+	})
+}
+
+// clearTempFiles remove the temp files which are not used by undone tasks,
+// the aria2 dir is cleared after the download tasks are resumed, and the
+// unfinished resumable uploads are kept until expired
+func clearTempFiles() {
+	inUse := fs.TempFilesInUse()
+	entries, err := os.ReadDir(conf.Conf.TempDir)
+	if err != nil {
+		log.Errorf("failed read temp dir: %+v", err)
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(conf.Conf.TempDir, entry.Name())
+		if entry.Name() == "aria2" || entry.Name() == "tus" || utils.SliceContains(inUse, path) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			log.Errorf("failed delete temp file: %+v", err)
+		}
+	}
+}
+
+// clearAria2TempDirs remove the temp dirs of aria2 which are not used by undone tasks
+func clearAria2TempDirs() {
+	inUse := aria2.TempDirsInUse()
+	dir := filepath.Join(conf.Conf.TempDir, "aria2")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read aria2 temp dir: %+v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if utils.SliceContains(inUse, path) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			log.Errorf("failed delete aria2 temp dir: %+v", err)
+		}
+	}
+}
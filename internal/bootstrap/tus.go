@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/tus"
+)
+
+// InitTus clear the expired resumable uploads every hour
+func InitTus() {
+	tus.ClearExpired()
+	go func() {
+		for range time.Tick(time.Hour) {
+			tus.ClearExpired()
+		}
+	}()
+}
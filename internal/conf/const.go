@@ -31,9 +31,22 @@ const (
 	CustomizeHead  = "customize_head"
 	CustomizeBody  = "customize_body"
 	LinkExpiration = "link_expiration"
+	DownLimit      = "down_limit"
+	UpLimit        = "up_limit"
+	TusExpiration  = "tus_expiration"
+	UploadPartSize = "upload_part_size"
+	UploadThreads  = "upload_threads"
 
-	Aria2Uri    = "aria2_uri"
-	Aria2Secret = "aria2_secret"
+	Aria2Uri        = "aria2_uri"
+	Aria2Secret     = "aria2_secret"
+	QbittorrentUrl  = "qbittorrent_url"
+	TransmissionUrl = "transmission_url"
+
+	CopyTaskWorkers       = "copy_task_workers"
+	UploadTaskWorkers     = "upload_task_workers"
+	DecompressTaskWorkers = "decompress_task_workers"
+	DownTaskWorkers       = "down_task_workers"
+	TransferTaskWorkers   = "transfer_task_workers"
 
 	Token = "token"
 )
@@ -39,6 +39,15 @@ func GetAccounts(pageIndex, pageSize int) ([]model.Account, int64, error) {
 	return accounts, count, nil
 }
 
+// GetAllAccounts Get all accounts from database order by index, used to load accounts while starting
+func GetAllAccounts() ([]model.Account, error) {
+	var accounts []model.Account
+	if err := db.Order(columnName("index")).Find(&accounts).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return accounts, nil
+}
+
 // GetAccountById Get Account by id, used to update account usually
 func GetAccountById(id uint) (*model.Account, error) {
 	var account model.Account
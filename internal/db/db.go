@@ -10,7 +10,7 @@ var db gorm.DB
 
 func Init(d *gorm.DB) {
 	db = *d
-	err := db.AutoMigrate(new(model.Account), new(model.User), new(model.Meta), new(model.SettingItem))
+	err := db.AutoMigrate(new(model.Account), new(model.User), new(model.Meta), new(model.SettingItem), new(model.TaskItem), new(model.SyncJob))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
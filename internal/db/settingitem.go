@@ -72,12 +72,36 @@ func GetSettingItemsByGroup(group int) ([]model.SettingItem, error) {
 
 func SaveSettingItems(items []model.SettingItem) error {
 	settingsMap = nil
-	return errors.WithStack(db.Save(items).Error)
+	if err := db.Save(items).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	for _, item := range items {
+		callSettingHook(item)
+	}
+	return nil
 }
 
 func SaveSettingItem(item model.SettingItem) error {
 	settingsMap = nil
-	return errors.WithStack(db.Save(item).Error)
+	if err := db.Save(item).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	callSettingHook(item)
+	return nil
+}
+
+var settingHooks = map[string]func(value string){}
+
+// RegisterSettingHook register a func called with the new value after the setting saved,
+// so that the change takes effect without restart
+func RegisterSettingHook(key string, hook func(value string)) {
+	settingHooks[key] = hook
+}
+
+func callSettingHook(item model.SettingItem) {
+	if hook, ok := settingHooks[item.Key]; ok {
+		hook(item.Value)
+	}
 }
 
 func DeleteSettingItemByKey(key string) error {
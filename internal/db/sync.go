@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func GetSyncJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	jobDB := db.Model(&model.SyncJob{})
+	var count int64
+	if err := jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	var jobs []model.SyncJob
+	if err := jobDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledSyncJobs() ([]model.SyncJob, error) {
+	var jobs []model.SyncJob
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("disabled")), false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, nil
+}
+
+func DeleteSyncJobById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
+
+// SetSyncJobLastRun only update the last run of the job, so that it won't overwrite the job updated meanwhile
+func SetSyncJobLastRun(id uint, taskID uint64, lastRun time.Time) error {
+	return errors.WithStack(db.Model(&model.SyncJob{ID: id}).Updates(model.SyncJob{LastTaskID: taskID, LastRun: lastRun}).Error)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// TaskStore persist the tasks of a task manager in database, the tasks are distinguished by Type
+type TaskStore struct {
+	Type string
+}
+
+func (s TaskStore) Save(r task.Record) error {
+	attempts, err := utils.Json.MarshalToString(r.Attempts)
+	if err != nil {
+		return errors.Wrap(err, "failed marshal attempts")
+	}
+	item := model.TaskItem{
+		Type:       s.Type,
+		ID:         r.ID,
+		Name:       r.Name,
+		Kind:       r.Kind,
+		Args:       r.Args,
+		State:      r.State,
+		Status:     r.Status,
+		Progress:   r.Progress,
+		Error:      r.Error,
+		Checkpoint: r.Checkpoint,
+		ParentID:   r.ParentID,
+		Group:      r.Group,
+		Size:       r.Size,
+		Creator:    r.Creator,
+		Priority:   r.Priority,
+		Attempts:   attempts,
+	}
+	// keep created_at of the existing one
+	var old model.TaskItem
+	if err := db.Where(&model.TaskItem{Type: s.Type, ID: r.ID}).Limit(1).Find(&old).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	item.CreatedAt = old.CreatedAt
+	return errors.WithStack(db.Save(&item).Error)
+}
+
+func (s TaskStore) Delete(id string) error {
+	return errors.WithStack(db.Where(&model.TaskItem{Type: s.Type, ID: id}).Delete(&model.TaskItem{}).Error)
+}
+
+func (s TaskStore) List() ([]task.Record, error) {
+	var items []model.TaskItem
+	if err := db.Where(&model.TaskItem{Type: s.Type}).Order(columnName("created_at")).Find(&items).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	records := make([]task.Record, len(items))
+	for i, item := range items {
+		var attempts []task.Attempt
+		// the attempts are only history, so the broken one is ignored
+		_ = utils.Json.UnmarshalFromString(item.Attempts, &attempts)
+		records[i] = task.Record{
+			ID:         item.ID,
+			Name:       item.Name,
+			Kind:       item.Kind,
+			Args:       item.Args,
+			State:      item.State,
+			Status:     item.Status,
+			Progress:   item.Progress,
+			Error:      item.Error,
+			Checkpoint: item.Checkpoint,
+			ParentID:   item.ParentID,
+			Group:      item.Group,
+			Size:       item.Size,
+			Creator:    item.Creator,
+			Priority:   item.Priority,
+			Attempts:   attempts,
+		}
+	}
+	return records, nil
+}
+
+var _ task.Store = TaskStore{}
@@ -7,6 +7,8 @@ type Config struct {
 	OnlyProxy bool
 	NoCache   bool
 	NoUpload  bool
+	// Hash is true if the driver can report the hashes of the put files
+	Hash bool
 }
 
 func (c Config) MustProxy() bool {
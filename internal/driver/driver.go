@@ -2,8 +2,10 @@ package driver
 
 import (
 	"context"
+	"io"
 
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Driver interface {
@@ -21,6 +23,8 @@ type Meta interface {
 	Drop(ctx context.Context) error
 	// GetAccount just get raw account
 	GetAccount() model.Account
+	// SetStatus record the init status of the account
+	SetStatus(status string)
 	GetAddition() Additional
 }
 
@@ -56,4 +60,29 @@ type Writer interface {
 	Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up UpdateProgress) error
 }
 
+// MultipartUploader is implemented by the drivers whose backend accepts a file by parts,
+// then the file is uploaded by parts concurrently instead of Put of Writer
+type MultipartUploader interface {
+	// InitUpload start the upload of `stream` to `dstDir`, the PartSize of `upload` is the
+	// suggested one, the driver can change it to fit the limits of its backend
+	InitUpload(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, upload *model.MultipartUpload) error
+	// UploadPart upload the part numbered from 1, it's called concurrently,
+	// the part can be seeked to the start to retry
+	UploadPart(ctx context.Context, upload *model.MultipartUpload, number int, part io.ReadSeeker, size int64) (*model.UploadedPart, error)
+	// CompleteUpload merge the uploaded parts, which are ordered by number
+	CompleteUpload(ctx context.Context, upload *model.MultipartUpload, parts []model.UploadedPart) error
+	// AbortUpload discard the uploaded parts
+	AbortUpload(ctx context.Context, upload *model.MultipartUpload) error
+}
+
+// RapidUploader is implemented by the drivers whose backend can put a file by its hashes
+// without the content, if the same content is already stored in the backend
+type RapidUploader interface {
+	// RapidHashTypes return the types of the hashes needed by RapidPut
+	RapidHashTypes() []utils.HashType
+	// RapidPut put `stream` to `dstDir` by the hashes without reading it, ok is false
+	// if the content is unknown to the backend, then it's put by Put of Writer
+	RapidPut(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, hashes utils.HashInfo) (ok bool, err error)
+}
+
 type UpdateProgress func(percentage int)
@@ -0,0 +1,24 @@
+package driver
+
+import "io"
+
+// ProgressReader wrap a reader and report the percentage of read bytes by UpdateProgress
+type ProgressReader struct {
+	io.Reader
+	total int64
+	read  int64
+	up    UpdateProgress
+}
+
+func NewProgressReader(r io.Reader, total int64, up UpdateProgress) *ProgressReader {
+	return &ProgressReader{Reader: r, total: total, up: up}
+}
+
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.Reader.Read(b)
+	p.read += int64(n)
+	if p.total > 0 && p.up != nil {
+		p.up(int(p.read * 100 / p.total))
+	}
+	return n, err
+}
@@ -9,8 +9,11 @@ var (
 	NotSupport   = errors.New("not support")
 	RelativePath = errors.New("access using relative path is not allowed")
 
+	AccountNotFound = errors.New("account not found")
+
 	MoveBetweenTwoAccounts = errors.New("can't move files between two account, try to copy")
 	UploadNotSupported     = errors.New("upload not supported")
+	VerifyFailed           = errors.New("the put file is different from the source")
 
 	MetaNotFound = errors.New("meta not found")
 )
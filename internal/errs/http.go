@@ -0,0 +1,22 @@
+package errs
+
+import (
+	"net/http"
+)
+
+// StatusError is the unexpected http response of the storage backends
+type StatusError struct {
+	Code int
+	Msg  string
+}
+
+func (e StatusError) Error() string {
+	return e.Msg
+}
+
+// Retryable implements task.Retryable, server errors and rate limits are transient
+func (e StatusError) Retryable() bool {
+	return e.Code >= http.StatusInternalServerError ||
+		e.Code == http.StatusTooManyRequests ||
+		e.Code == http.StatusRequestTimeout
+}
@@ -0,0 +1,440 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/bodgit/sevenzip"
+	"github.com/pkg/errors"
+)
+
+// Archives are browsed as folders, a path like /account/backup.zip/dir/file.txt
+// is the entry dir/file.txt in /account/backup.zip. Only the index of the archive
+// and the data of the requested entry are read, by range reads on the archive's link.
+// tar.gz and the solid blocks of 7z can't be read randomly, so their entries are
+// read by decompressing from the beginning of the archive or the block.
+// Browsing rar is not supported.
+
+const (
+	archiveZip   = "zip"
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archive7z    = "7z"
+	archiveRar   = "rar"
+)
+
+func archiveType(name string) string {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		return archiveTarGz
+	}
+	switch utils.Ext(name) {
+	case "zip":
+		return archiveZip
+	case "tar":
+		return archiveTar
+	case "7z":
+		return archive7z
+	case "rar":
+		return archiveRar
+	}
+	return ""
+}
+
+type archiveEntry struct {
+	model.Object
+	zipFile *zip.File
+	// the reader of zipFile, which is bound to the ctx of the request to read it
+	zipReader *rangeReaderAt
+	// offset of the data of a tar entry
+	offset int64
+	// the position of a tar.gz or 7z entry in the archive, to find it again
+	seq int
+}
+
+type archiveIndex struct {
+	size     int64
+	modified time.Time
+	entries  map[string]*archiveEntry
+	children map[string][]model.Obj
+}
+
+var archiveCache = cache.NewMemCache(cache.WithShards[*archiveIndex](16))
+var archiveG singleflight.Group[*archiveIndex]
+
+// findArchive find the archive file in the path,
+// return the path of the archive, the archive and the path inside the archive
+func findArchive(ctx context.Context, path string) (string, model.Obj, string, bool) {
+	path = utils.StandardizePath(path)
+	names := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, name := range names {
+		if archiveType(name) == "" {
+			continue
+		}
+		archivePath := "/" + strings.Join(names[:i+1], "/")
+		// use operations rather than get, the archive itself is never in an archive
+		account, actualPath, err := operations.GetAccountAndActualPath(archivePath)
+		if err != nil {
+			return "", nil, "", false
+		}
+		obj, err := operations.Get(ctx, account, actualPath)
+		if err != nil {
+			return "", nil, "", false
+		}
+		if obj.IsDir() {
+			continue
+		}
+		return archivePath, obj, "/" + strings.Join(names[i+1:], "/"), true
+	}
+	return "", nil, "", false
+}
+
+func getArchiveIndex(ctx context.Context, archivePath string, archive model.Obj) (*archiveIndex, error) {
+	if index, ok := archiveCache.Get(archivePath); ok &&
+		index.size == archive.GetSize() && index.modified.Equal(archive.ModTime()) {
+		return index, nil
+	}
+	index, err, _ := archiveG.Do(archivePath, func() (*archiveIndex, error) {
+		index := &archiveIndex{
+			size:     archive.GetSize(),
+			modified: archive.ModTime(),
+			entries:  make(map[string]*archiveEntry),
+			children: make(map[string][]model.Obj),
+		}
+		var err error
+		switch t := archiveType(archivePath); t {
+		case archiveZip:
+			err = index.readZip(ctx, archivePath)
+		case archiveTar:
+			err = index.readTar(ctx, archivePath)
+		case archiveTarGz:
+			err = index.readTarGz(ctx, archivePath)
+		case archive7z:
+			err = index.read7z(ctx, archivePath)
+		default:
+			err = errors.Wrapf(errs.NotSupport, "browsing %s archive", t)
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed read archive %s", archivePath)
+		}
+		index.build()
+		archiveCache.Set(archivePath, index, cache.WithEx[*archiveIndex](time.Minute*time.Duration(conf.Conf.CaCheExpiration)))
+		return index, nil
+	})
+	return index, err
+}
+
+func (index *archiveIndex) add(name string, entry *archiveEntry) {
+	name = stdpath.Clean("/" + name)
+	if name == "/" {
+		return
+	}
+	entry.ID = name
+	entry.Name = stdpath.Base(name)
+	if entry.Modified.IsZero() {
+		entry.Modified = index.modified
+	}
+	// an entry may appear more than once, the last one wins
+	index.entries[name] = entry
+}
+
+// build add the dirs that have no entry of their own and group the entries by dir
+func (index *archiveIndex) build() {
+	for name := range index.entries {
+		for dir := stdpath.Dir(name); dir != "/"; dir = stdpath.Dir(dir) {
+			if e, ok := index.entries[dir]; ok {
+				if !e.IsFolder {
+					// a file can't contain other files
+					e.Object = model.Object{ID: dir, Name: e.Name, Modified: e.Modified, IsFolder: true}
+					e.zipFile = nil
+				}
+				continue
+			}
+			index.entries[dir] = &archiveEntry{
+				Object: model.Object{ID: dir, Name: stdpath.Base(dir), Modified: index.modified, IsFolder: true},
+			}
+		}
+	}
+	for name, entry := range index.entries {
+		dir := stdpath.Dir(name)
+		index.children[dir] = append(index.children[dir], entry)
+	}
+	for _, objs := range index.children {
+		sort.Slice(objs, func(i, j int) bool {
+			return objs[i].GetName() < objs[j].GetName()
+		})
+	}
+}
+
+func (index *archiveIndex) readZip(ctx context.Context, archivePath string) error {
+	// the files of zip keep the reader to get their data offset later,
+	// which is bound to the ctx of the later requests then
+	ra := newRangeReaderAt(ctx, archivePath, index.size)
+	var r *zip.Reader
+	err := ra.with(ctx, func() (err error) {
+		r, err = zip.NewReader(ra, index.size)
+		return err
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, f := range r.File {
+		isDir := strings.HasSuffix(f.Name, "/") || f.FileInfo().IsDir()
+		entry := &archiveEntry{
+			Object: model.Object{
+				Modified: f.Modified,
+				IsFolder: isDir,
+			},
+		}
+		if !isDir {
+			entry.Size = int64(f.UncompressedSize64)
+			entry.zipFile = f
+			entry.zipReader = ra
+		}
+		index.add(f.Name, entry)
+	}
+	return nil
+}
+
+func (index *archiveIndex) readTar(ctx context.Context, archivePath string) error {
+	// the section reader is a seeker, so the data of entries are skipped rather than read
+	sr := io.NewSectionReader(newRangeReaderAt(ctx, archivePath, index.size), 0, index.size)
+	r := tar.NewReader(sr)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		entry := &archiveEntry{
+			Object: model.Object{Modified: hdr.ModTime},
+		}
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			entry.IsFolder = true
+		case hdr.FileInfo().Mode().IsRegular():
+			entry.Size = hdr.Size
+			entry.offset, err = sr.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			// links, devices, etc.
+			continue
+		}
+		index.add(hdr.Name, entry)
+	}
+}
+
+func (index *archiveIndex) readTarGz(ctx context.Context, archivePath string) error {
+	rc, err := OpenRange(ctx, archivePath, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	gr, err := gzip.NewReader(rc)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r := tar.NewReader(gr)
+	for seq := 0; ; seq++ {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		entry := &archiveEntry{
+			Object: model.Object{Modified: hdr.ModTime},
+			seq:    seq,
+		}
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			entry.IsFolder = true
+		case hdr.FileInfo().Mode().IsRegular():
+			entry.Size = hdr.Size
+		default:
+			continue
+		}
+		index.add(hdr.Name, entry)
+	}
+}
+
+func (index *archiveIndex) read7z(ctx context.Context, archivePath string) error {
+	r, err := sevenzip.NewReader(newRangeReaderAt(ctx, archivePath, index.size), index.size)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for seq, f := range r.File {
+		isDir := f.FileInfo().IsDir()
+		entry := &archiveEntry{
+			Object: model.Object{
+				Modified: f.Modified,
+				IsFolder: isDir,
+			},
+			seq: seq,
+		}
+		if !isDir {
+			entry.Size = int64(f.UncompressedSize)
+		}
+		index.add(f.Name, entry)
+	}
+	return nil
+}
+
+func getArchiveEntry(ctx context.Context, archivePath string, archive model.Obj, innerPath string) (*archiveEntry, error) {
+	index, err := getArchiveIndex(ctx, archivePath, archive)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := index.entries[innerPath]
+	if !ok {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	return entry, nil
+}
+
+func listArchive(ctx context.Context, archivePath string, archive model.Obj, innerPath string) ([]model.Obj, error) {
+	index, err := getArchiveIndex(ctx, archivePath, archive)
+	if err != nil {
+		return nil, err
+	}
+	if innerPath != "/" {
+		entry, ok := index.entries[innerPath]
+		if !ok {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		if !entry.IsDir() {
+			return nil, errors.WithStack(errs.NotFolder)
+		}
+	}
+	// copy it, the caller may hide or sort the objs
+	objs := make([]model.Obj, len(index.children[innerPath]))
+	copy(objs, index.children[innerPath])
+	return objs, nil
+}
+
+func linkArchive(ctx context.Context, archivePath string, archive model.Obj, innerPath string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	entry, err := getArchiveEntry(ctx, archivePath, archive, innerPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.IsDir() {
+		return nil, nil, errors.WithStack(errs.NotFile)
+	}
+	size := entry.GetSize()
+	rangeReader := func(ctx context.Context, start, length int64) (io.ReadCloser, error) {
+		if length < 0 || start+length > size {
+			length = size - start
+		}
+		if length <= 0 {
+			return ioutil.NopCloser(io.LimitReader(nil, 0)), nil
+		}
+		return entry.open(ctx, archivePath, archive.GetSize(), start, length)
+	}
+	return &model.Link{RangeReader: model.RangeReaderFunc(rangeReader)}, entry, nil
+}
+
+// open read length bytes of the entry from start
+func (entry *archiveEntry) open(ctx context.Context, archivePath string, archiveSize, start, length int64) (io.ReadCloser, error) {
+	switch archiveType(archivePath) {
+	case archiveTarGz, archive7z:
+		rc, err := entry.stream(ctx, archivePath, archiveSize)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.CopyN(ioutil.Discard, rc, start); err != nil {
+			_ = rc.Close()
+			return nil, errors.Wrapf(err, "error while skip to %d", start)
+		}
+		return utils.ReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}, nil
+	}
+	f := entry.zipFile
+	if f == nil {
+		return OpenRange(ctx, archivePath, entry.offset+start, length)
+	}
+	if f.Flags&0x1 != 0 {
+		return nil, errors.Errorf("%s is encrypted", f.Name)
+	}
+	var offset int64
+	err := entry.zipReader.with(ctx, func() (err error) {
+		offset, err = f.DataOffset()
+		return err
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch f.Method {
+	case zip.Store:
+		return OpenRange(ctx, archivePath, offset+start, length)
+	case zip.Deflate:
+		rc, err := OpenRange(ctx, archivePath, offset, int64(f.CompressedSize64))
+		if err != nil {
+			return nil, err
+		}
+		fr := flate.NewReader(rc)
+		if _, err = io.CopyN(ioutil.Discard, fr, start); err != nil {
+			_ = fr.Close()
+			_ = rc.Close()
+			return nil, errors.Wrapf(err, "error while skip to %d", start)
+		}
+		return utils.ReadCloser{Reader: io.LimitReader(fr, length), Closer: rc}, nil
+	default:
+		return nil, errors.Errorf("compression method %d of %s is not supported", f.Method, f.Name)
+	}
+}
+
+// stream read the tar.gz or 7z entry from the beginning, the archive is read again
+// with the ctx of the request, rather than keeping the reader of the index
+func (entry *archiveEntry) stream(ctx context.Context, archivePath string, archiveSize int64) (io.ReadCloser, error) {
+	if archiveType(archivePath) == archive7z {
+		r, err := sevenzip.NewReader(newRangeReaderAt(ctx, archivePath, archiveSize), archiveSize)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if entry.seq >= len(r.File) {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		rc, err := r.File[entry.seq].Open()
+		return rc, errors.WithStack(err)
+	}
+	rc, err := OpenRange(ctx, archivePath, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, errors.WithStack(err)
+	}
+	r := tar.NewReader(gr)
+	for seq := 0; seq <= entry.seq; seq++ {
+		if _, err = r.Next(); err != nil {
+			_ = rc.Close()
+			if err == io.EOF {
+				err = errs.ObjectNotFound
+			}
+			return nil, errors.WithStack(err)
+		}
+	}
+	return utils.ReadCloser{Reader: r, Closer: rc}, nil
+}
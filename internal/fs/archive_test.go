@@ -0,0 +1,239 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+	// the streams put to Local are cached here to compute the hashes
+	conf.Conf.TempDir = os.TempDir()
+}
+
+var archiveFiles = map[string][]byte{
+	"a.txt":         []byte("hello archive"),
+	"dir/b.txt":     bytes.Repeat([]byte("0123456789"), 10000),
+	"dir/sub/c.txt": []byte("deep"),
+}
+
+func writeZip(t *testing.T, path string) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"} {
+		method := zip.Deflate
+		if name == "a.txt" {
+			method = zip.Store
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.Write(archiveFiles[name])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, path string) {
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	_ = w.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"} {
+		err := w.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(archiveFiles[name]))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(archiveFiles[name])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// write7z write the files stored by the copy method in one block, with a plain header
+func write7z(t *testing.T, path string) {
+	number := func(buf *bytes.Buffer, v uint64) {
+		n := 0
+		for n < 8 && v >= 1<<(7*(n+1)) {
+			n++
+		}
+		buf.WriteByte(byte(0xff<<(8-n)) | byte(v>>(8*n)))
+		for i := 0; i < n; i++ {
+			buf.WriteByte(byte(v >> (8 * i)))
+		}
+	}
+	names := []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"}
+	data := new(bytes.Buffer)
+	for _, name := range names {
+		data.Write(archiveFiles[name])
+	}
+	h := new(bytes.Buffer)
+	// header, main streams info, pack info
+	h.Write([]byte{0x01, 0x04, 0x06, 0x00, 0x01, 0x09})
+	number(h, uint64(data.Len()))
+	// unpack info of one folder with the copy coder
+	h.Write([]byte{0x00, 0x07, 0x0b, 0x01, 0x00, 0x01, 0x01, 0x00, 0x0c})
+	number(h, uint64(data.Len()))
+	// sub streams info, the size of the last one is the rest
+	h.Write([]byte{0x00, 0x08, 0x0d, byte(len(names)), 0x09})
+	for _, name := range names[:len(names)-1] {
+		number(h, uint64(len(archiveFiles[name])))
+	}
+	// all the crc of the files are defined
+	h.Write([]byte{0x0a, 0x01})
+	for _, name := range names {
+		_ = binary.Write(h, binary.LittleEndian, crc32.ChecksumIEEE(archiveFiles[name]))
+	}
+	h.Write([]byte{0x00, 0x00})
+	// files info with the names only
+	nameBytes := new(bytes.Buffer)
+	for _, name := range names {
+		for _, r := range name + "\x00" {
+			_ = binary.Write(nameBytes, binary.LittleEndian, uint16(r))
+		}
+	}
+	h.Write([]byte{0x05, byte(len(names)), 0x11})
+	number(h, uint64(nameBytes.Len()+1))
+	h.WriteByte(0x00)
+	h.Write(nameBytes.Bytes())
+	h.Write([]byte{0x00, 0x00})
+
+	start := make([]byte, 20)
+	binary.LittleEndian.PutUint64(start, uint64(data.Len()))
+	binary.LittleEndian.PutUint64(start[8:], uint64(h.Len()))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(h.Bytes()))
+	buf := bytes.NewBuffer([]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0, 4})
+	_ = binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(start))
+	buf.Write(start)
+	buf.Write(data.Bytes())
+	buf.Write(h.Bytes())
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	root := t.TempDir()
+	writeZip(t, filepath.Join(root, "test.zip"))
+	writeTar(t, filepath.Join(root, "test.tar"))
+	writeTarGz(t, filepath.Join(root, "test.tar.gz"))
+	write7z(t, filepath.Join(root, "test.7z"))
+	if err := ioutil.WriteFile(filepath.Join(root, "test.rar"), []byte("rar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "Local",
+		VirtualPath: "/archive",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create account: %+v", err)
+	}
+	ctx := context.WithValue(context.Background(), "meta", (*model.Meta)(nil))
+	ctx = context.WithValue(ctx, "user", &model.User{Role: model.ADMIN})
+	// the index is read by a request which is done before the entries are read
+	reqCtx, cancel := context.WithCancel(ctx)
+	if _, err := List(reqCtx, "/archive/test.zip"); err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	cancel()
+	if _, err := List(ctx, "/archive/test.rar"); !errors.Is(err, errs.NotSupport) {
+		t.Errorf("expect not support error for rar, got %+v", err)
+	}
+	for _, archive := range []string{"/archive/test.zip", "/archive/test.tar", "/archive/test.tar.gz", "/archive/test.7z"} {
+		objs, err := List(ctx, archive)
+		if err != nil {
+			t.Fatalf("failed to list %s: %+v", archive, err)
+		}
+		if len(objs) != 2 || objs[0].GetName() != "a.txt" || objs[1].GetName() != "dir" || !objs[1].IsDir() {
+			t.Errorf("unexpected list of %s: %+v", archive, objs)
+		}
+		objs, err = List(ctx, archive+"/dir")
+		if err != nil {
+			t.Fatalf("failed to list %s/dir: %+v", archive, err)
+		}
+		if len(objs) != 2 || objs[0].GetName() != "b.txt" || objs[1].GetName() != "sub" {
+			t.Errorf("unexpected list of %s/dir: %+v", archive, objs)
+		}
+		for name, content := range archiveFiles {
+			path := archive + "/" + name
+			obj, err := Get(ctx, path)
+			if err != nil {
+				t.Fatalf("failed to get %s: %+v", path, err)
+			}
+			if obj.IsDir() || obj.GetSize() != int64(len(content)) {
+				t.Errorf("unexpected obj of %s: %+v", path, obj)
+			}
+			link, _, err := Link(ctx, path, model.LinkArgs{})
+			if err != nil {
+				t.Fatalf("failed to link %s: %+v", path, err)
+			}
+			rc, err := link.RangeReader.RangeRead(ctx, 0, -1)
+			if err != nil {
+				t.Fatalf("failed to read %s: %+v", path, err)
+			}
+			data, err := ioutil.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil || !bytes.Equal(data, content) {
+				t.Errorf("unexpected content of %s: %v", path, err)
+			}
+		}
+		path := archive + "/dir/b.txt"
+		link, _, err := Link(ctx, path, model.LinkArgs{})
+		if err != nil {
+			t.Fatalf("failed to link %s: %+v", path, err)
+		}
+		rc, err := link.RangeReader.RangeRead(ctx, 50005, 10)
+		if err != nil {
+			t.Fatalf("failed to read range of %s: %+v", path, err)
+		}
+		data, _ := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if string(data) != "5678901234" {
+			t.Errorf("unexpected range of %s: %q", path, data)
+		}
+		if _, err := Get(ctx, archive+"/missing.txt"); err == nil {
+			t.Errorf("expect error for missing entry in %s", archive)
+		}
+	}
+	// the index of a removed archive is not used
+	if err := os.Remove(filepath.Join(root, "test.zip")); err != nil {
+		t.Fatal(err)
+	}
+	ClearCache("/archive")
+	if _, err := List(ctx, "/archive/test.zip"); err == nil {
+		t.Errorf("expect error for removed archive")
+	}
+}
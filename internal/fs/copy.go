@@ -1,15 +1,24 @@
 package fs
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
 	stdpath "path"
 	"sync/atomic"
 
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
 	"github.com/pkg/errors"
@@ -19,9 +28,31 @@ var CopyTaskManager = task.NewTaskManager(3, func(tid *uint64) {
 	atomic.AddUint64(tid, 1)
 })
 
+// kinds of copy tasks
+const (
+	copyKind     = "copy"
+	copyFileKind = "copy_file"
+)
+
+func init() {
+	CopyTaskManager.SetRetryPolicy(task.DefaultRetryPolicy)
+	CopyTaskManager.RegisterResumer(copyKind, resumeCopy)
+	CopyTaskManager.RegisterResumer(copyFileKind, resumeCopy)
+}
+
+// copyArgs is the persisted args of copy tasks, accounts are saved as their virtual path
+type copyArgs struct {
+	SrcAccount string `json:"src_account"`
+	SrcPath    string `json:"src_path"`
+	DstAccount string `json:"dst_account"`
+	DstPath    string `json:"dst_path"`
+	// DstName is the name of the copied object, it's the src name if empty
+	DstName string `json:"dst_name,omitempty"`
+}
+
 // Copy if in an account, call move method
-// if not, add copy task
-func _copy(ctx context.Context, srcObjPath, dstDirPath string) (bool, error) {
+// if not, add copy task. The copied object is named dstName.
+func _copy(ctx context.Context, srcObjPath, dstDirPath, dstName string) (bool, error) {
 	srcAccount, srcObjActualPath, err := operations.GetAccountAndActualPath(srcObjPath)
 	if err != nil {
 		return false, errors.WithMessage(err, "failed get src account")
@@ -30,21 +61,36 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string) (bool, error) {
 	if err != nil {
 		return false, errors.WithMessage(err, "failed get dst account")
 	}
+	srcName := stdpath.Base(srcObjPath)
+	if dstName == srcName {
+		dstName = ""
+	}
 	// copy if in an account, just call driver.Copy
 	if srcAccount.GetAccount() == dstAccount.GetAccount() {
-		return false, operations.Copy(ctx, srcAccount, srcObjActualPath, dstDirActualPath)
-	}
-	// not in an account
-	CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-		Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcAccount.GetAccount().VirtualPath, srcObjActualPath, dstAccount.GetAccount().VirtualPath, dstDirActualPath),
-		Func: func(task *task.Task[uint64]) error {
-			return copyBetween2Accounts(task, srcAccount, dstAccount, srcObjActualPath, dstDirActualPath)
-		},
-	}))
+		// the driver copies with the src name, so it's renamed after copied,
+		// unless the dst dir has an object of the src name which can't be overwritten
+		if dstName == "" {
+			return false, operations.Copy(ctx, srcAccount, srcObjActualPath, dstDirActualPath)
+		}
+		_, err := operations.Get(ctx, dstAccount, stdpath.Join(dstDirActualPath, srcName))
+		if errs.IsObjectNotFound(err) {
+			if err := operations.Copy(ctx, srcAccount, srcObjActualPath, dstDirActualPath); err != nil {
+				return false, err
+			}
+			return false, operations.Rename(ctx, dstAccount, stdpath.Join(dstDirActualPath, srcName), dstName)
+		}
+		if err != nil {
+			return false, errors.WithMessage(err, "failed get dst object")
+		}
+	}
+	// not in an account, or the name can't be changed after copied by the driver
+	t := newCopyTask(copyKind, srcAccount, dstAccount, srcObjActualPath, dstDirActualPath, dstName, 0)
+	t.Creator = creator(ctx)
+	CopyTaskManager.Submit(t)
 	return true, nil
 }
 
-func copyBetween2Accounts(t *task.Task[uint64], srcAccount, dstAccount driver.Driver, srcObjPath, dstDirPath string) error {
+func copyBetween2Accounts(t *task.Task[uint64], srcAccount, dstAccount driver.Driver, srcObjPath, dstDirPath, dstName string) error {
 	t.SetStatus("getting src object")
 	srcObj, err := operations.Get(t.Ctx, srcAccount, srcObjPath)
 	if err != nil {
@@ -56,42 +102,224 @@ func copyBetween2Accounts(t *task.Task[uint64], srcAccount, dstAccount driver.Dr
 		if err != nil {
 			return errors.WithMessagef(err, "failed list src [%s] objs", srcObjPath)
 		}
+		// the children submitted before it's paused or restarted are not submitted again,
+		// they are found by their args since the objs may be renamed, removed or reordered
+		submitted := make(map[string]struct{})
+		for _, child := range t.GetChildren() {
+			submitted[child.Args] = struct{}{}
+		}
+		if dstName == "" {
+			dstName = srcObj.GetName()
+		}
+		dstObjPath := stdpath.Join(dstDirPath, dstName)
 		for _, obj := range objs {
 			if utils.IsCanceled(t.Ctx) {
 				return nil
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
-			dstObjPath := stdpath.Join(dstDirPath, obj.GetName())
-			CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-				Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcAccount.GetAccount().VirtualPath, srcObjPath, dstAccount.GetAccount().VirtualPath, dstObjPath),
-				Func: func(t *task.Task[uint64]) error {
-					return copyBetween2Accounts(t, srcAccount, dstAccount, srcObjPath, dstObjPath)
-				},
-			}))
+			var child *task.Task[uint64]
+			if obj.IsDir() {
+				child = newCopyTask(copyKind, srcAccount, dstAccount, srcObjPath, dstObjPath, "", 0)
+			} else {
+				child = newCopyTask(copyFileKind, srcAccount, dstAccount, srcObjPath, dstObjPath, "", obj.GetSize())
+			}
+			if _, ok := submitted[child.Args]; !ok {
+				CopyTaskManager.SubmitChild(t, child)
+			}
 		}
-	} else {
-		CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-			Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcAccount.GetAccount().VirtualPath, srcObjPath, dstAccount.GetAccount().VirtualPath, dstDirPath),
-			Func: func(t *task.Task[uint64]) error {
-				return copyFileBetween2Accounts(t, srcAccount, dstAccount, srcObjPath, dstDirPath)
-			},
-		}))
+	} else if len(t.GetChildren()) == 0 {
+		CopyTaskManager.SubmitChild(t, newCopyTask(copyFileKind, srcAccount, dstAccount, srcObjPath, dstDirPath, dstName, srcObj.GetSize()))
 	}
 	return nil
 }
 
-func copyFileBetween2Accounts(tsk *task.Task[uint64], srcAccount, dstAccount driver.Driver, srcFilePath, dstDirPath string) error {
+// copyCheckpoint is the checkpoint of copy file tasks
+type copyCheckpoint struct {
+	TempFile string `json:"temp_file"`
+}
+
+// copyFileBetween2Accounts download the src file to a temp file then upload it,
+// so that a paused task continues downloading from the end of the temp file
+func copyFileBetween2Accounts(tsk *task.Task[uint64], srcAccount, dstAccount driver.Driver, srcFilePath, dstDirPath, dstName string) (err error) {
 	srcFile, err := operations.Get(tsk.Ctx, srcAccount, srcFilePath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
 	}
-	link, _, err := operations.Link(tsk.Ctx, srcAccount, srcFilePath, model.LinkArgs{})
+	if dstName != "" {
+		srcFile = &renamedObj{Obj: srcFile, name: dstName}
+	}
+	mimetype := mime.TypeByExtension(stdpath.Ext(srcFile.GetName()))
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+	var checkpoint copyCheckpoint
+	_ = utils.Json.UnmarshalFromString(tsk.GetCheckpoint(), &checkpoint)
+	// skip downloading if the dst account has the same file
+	ok, err := operations.RapidPut(tsk.Ctx, dstAccount, dstDirPath, &model.FileStream{
+		Obj:        srcFile,
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(nil)),
+		Mimetype:   mimetype,
+	})
+	if err != nil || ok {
+		if ok {
+			tsk.SetProgress(100)
+			// the part downloaded before the task was paused isn't needed
+			if checkpoint.TempFile != "" {
+				_ = os.Remove(checkpoint.TempFile)
+			}
+		}
+		return err
+	}
+	if checkpoint.TempFile == "" {
+		f, err := ioutil.TempFile(conf.Conf.TempDir, "copy-*")
+		if err != nil {
+			return errors.Wrap(err, "failed create temp file")
+		}
+		_ = f.Close()
+		checkpoint.TempFile = f.Name()
+		cp, _ := utils.Json.MarshalToString(checkpoint)
+		tsk.SetCheckpoint(cp)
+	}
+	defer func() {
+		// continue from the temp file when it's resumed or retried
+		if !tsk.WillRunAgain(err) {
+			_ = os.Remove(checkpoint.TempFile)
+		}
+	}()
+	if err := downloadTo(tsk, srcAccount, srcFilePath, srcFile.GetSize(), checkpoint.TempFile); err != nil {
+		return err
+	}
+	// the temp file is removed after put, so compute the hashes to verify before
+	hashes, err := operations.FileHashes(operations.HashOf(srcFile), checkpoint.TempFile, operations.DstHashTypes(dstAccount)...)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(checkpoint.TempFile)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
+		return errors.Wrap(err, "failed open temp file")
 	}
-	stream, err := getFileStreamFromLink(srcFile, link)
+	stream := &model.FileStream{
+		Obj:        srcFile,
+		ReadCloser: f,
+		Mimetype:   mimetype,
+	}
+	tsk.SetStatus("uploading")
+	err = operations.Put(tsk.Ctx, dstAccount, dstDirPath, stream, func(p int) {
+		tsk.SetProgress(50 + p/2)
+	})
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
+		return err
 	}
-	return operations.Put(tsk.Ctx, dstAccount, dstDirPath, stream, tsk.SetProgress)
+	tsk.SetStatus("verifying")
+	return operations.Verify(tsk.Ctx, dstAccount, stdpath.Join(dstDirPath, srcFile.GetName()), srcFile.GetSize(), hashes)
+}
+
+// downloadTo append the rest of the src file to the temp file
+func downloadTo(tsk *task.Task[uint64], srcAccount driver.Driver, srcPath string, size int64, tempFile string) error {
+	f, err := os.OpenFile(tempFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed open temp file")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if info.Size() >= size {
+		return nil
+	}
+	tsk.SetStatus("downloading")
+	link, _, err := operations.Link(tsk.Ctx, srcAccount, srcPath, model.LinkArgs{Header: rangeHeader(info.Size(), -1)})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", srcPath)
+	}
+	rc, err := openLinkRange(tsk.Ctx, link, info.Size(), -1)
+	if err != nil {
+		return errors.WithMessagef(err, "failed open [%s]", srcPath)
+	}
+	defer rc.Close()
+	r := ratelimit.NewReader(tsk.Ctx, rc, bandwidth.DownLimiters(bandwidth.UserOf(tsk.Ctx), srcAccount.GetAccount())...)
+	_, err = io.Copy(f, &progressReader{tsk: tsk, r: r, n: info.Size(), size: size})
+	return errors.Wrapf(err, "failed download [%s]", srcPath)
+}
+
+// progressReader report the progress of downloading, which is the first half of copying,
+// and stop reading when the task is canceled
+type progressReader struct {
+	tsk  *task.Task[uint64]
+	r    io.Reader
+	n    int64
+	size int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.tsk.Ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if p.size > 0 {
+		p.tsk.SetProgress(int(p.n * 50 / p.size))
+	}
+	return n, err
+}
+
+// newCopyTask create a copy task, the copy task is a group of the copy file tasks,
+// dstName is the name of the copied object or empty to keep the src name, size is the size of the file to copy
+func newCopyTask(kind string, srcAccount, dstAccount driver.Driver, srcPath, dstPath, dstName string, size int64) *task.Task[uint64] {
+	args, _ := utils.Json.MarshalToString(copyArgs{
+		SrcAccount: srcAccount.GetAccount().VirtualPath,
+		SrcPath:    srcPath,
+		DstAccount: dstAccount.GetAccount().VirtualPath,
+		DstPath:    dstPath,
+		DstName:    dstName,
+	})
+	return task.WithCancelCtx(&task.Task[uint64]{
+		Name:  fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcAccount.GetAccount().VirtualPath, srcPath, dstAccount.GetAccount().VirtualPath, dstPath),
+		Kind:  kind,
+		Args:  args,
+		Func:  copyFunc(kind, srcAccount, dstAccount, srcPath, dstPath, dstName),
+		Group: kind == copyKind,
+		Size:  size,
+	})
+}
+
+func copyFunc(kind string, srcAccount, dstAccount driver.Driver, srcPath, dstPath, dstName string) task.Func[uint64] {
+	return func(t *task.Task[uint64]) error {
+		if kind == copyFileKind {
+			return copyFileBetween2Accounts(t, srcAccount, dstAccount, srcPath, dstPath, dstName)
+		}
+		return copyBetween2Accounts(t, srcAccount, dstAccount, srcPath, dstPath, dstName)
+	}
+}
+
+func resumeCopy(t *task.Task[uint64]) error {
+	var args copyArgs
+	if err := utils.Json.UnmarshalFromString(t.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	srcAccount, err := operations.GetAccountByVirtualPath(args.SrcAccount)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src account")
+	}
+	dstAccount, err := operations.GetAccountByVirtualPath(args.DstAccount)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst account")
+	}
+	t.Func = copyFunc(t.Kind, srcAccount, dstAccount, args.SrcPath, args.DstPath, args.DstName)
+	return nil
+}
+
+// renamedObj is the src file put to the dst account with another name
+type renamedObj struct {
+	model.Obj
+	name string
+}
+
+func (r *renamedObj) GetName() string {
+	return r.name
+}
+
+func (r *renamedObj) GetHash() utils.HashInfo {
+	return operations.HashOf(r.Obj)
 }
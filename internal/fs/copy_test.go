@@ -0,0 +1,271 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// setupCopy create two Local accounts of the src and dst dir
+func setupCopy(t *testing.T, prefix string) (string, string) {
+	src, dst := t.TempDir(), t.TempDir()
+	tempDir := conf.Conf.TempDir
+	conf.Conf.TempDir = t.TempDir()
+	t.Cleanup(func() {
+		conf.Conf.TempDir = tempDir
+	})
+	for path, root := range map[string]string{prefix + "_src": src, prefix + "_dst": dst} {
+		err := operations.CreateAccount(context.Background(), model.Account{
+			Driver:      "Local",
+			VirtualPath: path,
+			Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+		})
+		if err != nil {
+			t.Fatalf("failed to create account: %+v", err)
+		}
+	}
+	return src, dst
+}
+
+func TestCopyFileFromCheckpoint(t *testing.T) {
+	src, dst := setupCopy(t, "/copy")
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := ioutil.WriteFile(filepath.Join(src, "file.txt"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the dst account can't rapid put the file, which is known to Local once it's got
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "PlainLocal",
+		VirtualPath: "/copy_plain",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(dst)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create account: %+v", err)
+	}
+	srcAccount, srcPath, _ := operations.GetAccountAndActualPath("/copy_src/file.txt")
+	dstAccount, dstPath, _ := operations.GetAccountAndActualPath("/copy_plain")
+
+	// the task was paused after downloading a part of the file
+	tempFile := filepath.Join(conf.Conf.TempDir, "copy-test")
+	if err := ioutil.WriteFile(tempFile, content[:3000], 0600); err != nil {
+		t.Fatal(err)
+	}
+	tsk := task.WithCancelCtx(&task.Task[uint64]{Name: "copy"})
+	checkpoint, _ := utils.Json.MarshalToString(copyCheckpoint{TempFile: tempFile})
+	tsk.SetCheckpoint(checkpoint)
+	if err := copyFileBetween2Accounts(tsk, srcAccount, dstAccount, srcPath, dstPath, ""); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dst, "file.txt"))
+	if err != nil || !bytes.Equal(data, content) {
+		t.Errorf("unexpected copied file: %v", err)
+	}
+	if utils.Exists(tempFile) {
+		t.Errorf("temp file should be removed")
+	}
+}
+
+func TestCopyFolder(t *testing.T) {
+	src, dst := setupCopy(t, "/copy_folder")
+	files := map[string][]byte{
+		"a.txt":         []byte("a"),
+		"dir/b.txt":     bytes.Repeat([]byte("b"), 1000),
+		"dir/sub/c.txt": []byte("c"),
+	}
+	for name, content := range files {
+		path := filepath.Join(src, "folder", name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Copy(context.Background(), "/copy_folder_src/folder", "/copy_folder_dst"); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	var root *task.Task[uint64]
+	for _, tsk := range CopyTaskManager.GetAll() {
+		if tsk.GetParent() == nil {
+			root = tsk
+		}
+	}
+	for i := 0; i < 100 && root.GetState() != task.SUCCEEDED; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if root.GetState() != task.SUCCEEDED {
+		t.Fatalf("copy task is %s: %s", root.GetState(), root.GetErrMsg())
+	}
+	if s := root.Stats(); s.Files != 3 || s.DoneFiles != 3 || s.Bytes != 1002 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	for name, content := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dst, "folder", name))
+		if err != nil || !bytes.Equal(data, content) {
+			t.Errorf("unexpected %s: %v", name, err)
+		}
+	}
+	CopyTaskManager.RemoveAll()
+}
+
+func TestCopyFolderResume(t *testing.T) {
+	src, _ := setupCopy(t, "/copy_resume")
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcAccount, srcPath, _ := operations.GetAccountAndActualPath("/copy_resume_src")
+	dstAccount, dstPath, _ := operations.GetAccountAndActualPath("/copy_resume_dst")
+	tsk := newCopyTask(copyKind, srcAccount, dstAccount, srcPath, dstPath, "", 0)
+	// b.txt was submitted before the task was paused, and a.txt is removed since
+	dstObjPath := stdpath.Join(dstPath, stdpath.Base(srcPath))
+	CopyTaskManager.SubmitChild(tsk, newCopyTask(copyFileKind, srcAccount, dstAccount,
+		stdpath.Join(srcPath, "b.txt"), dstObjPath, "", 5))
+	if err := os.Remove(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyBetween2Accounts(tsk, srcAccount, dstAccount, srcPath, dstPath, ""); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	var names []string
+	for _, child := range tsk.GetChildren() {
+		var args copyArgs
+		_ = utils.Json.UnmarshalFromString(child.Args, &args)
+		names = append(names, stdpath.Base(args.SrcPath))
+	}
+	if len(names) != 2 || names[0] != "b.txt" || names[1] != "c.txt" {
+		t.Errorf("expected b.txt not submitted again, got %v", names)
+	}
+	CopyTaskManager.RemoveAll()
+}
+
+func TestCopyAs(t *testing.T) {
+	src, dst := setupCopy(t, "/copy_as")
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "dir/b.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcAccount, srcPath, _ := operations.GetAccountAndActualPath("/copy_as_src")
+	dstAccount, dstPath, _ := operations.GetAccountAndActualPath("/copy_as_dst")
+	// the copy tasks put the file and the dir under the dst name
+	for name, dstName := range map[string]string{"a.txt": "c.txt", "dir": "renamed"} {
+		tsk := newCopyTask(copyKind, srcAccount, dstAccount, stdpath.Join(srcPath, name), dstPath, dstName, 0)
+		if err := copyBetween2Accounts(tsk, srcAccount, dstAccount, stdpath.Join(srcPath, name), dstPath, dstName); err != nil {
+			t.Fatalf("failed to copy %s: %+v", name, err)
+		}
+		for _, child := range tsk.GetChildren() {
+			if err := child.Func(child); err != nil {
+				t.Fatalf("failed to copy %s: %+v", child.Args, err)
+			}
+		}
+	}
+	for name, content := range map[string]string{"c.txt": "a.txt", "renamed/b.txt": "dir/b.txt"} {
+		if b, err := ioutil.ReadFile(filepath.Join(dst, name)); err != nil || string(b) != content {
+			t.Errorf("expected %s of %s, got %s %+v", name, content, b, err)
+		}
+	}
+	for _, name := range []string{"a.txt", "dir"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s under the src name, got %+v", name, err)
+		}
+	}
+	CopyTaskManager.RemoveAll()
+}
+
+// plainLocal is a Local driver which can't rapid put
+type plainLocal struct {
+	local.Driver
+}
+
+func (d *plainLocal) RapidHashTypes() []utils.HashType {
+	return []utils.HashType{"none"}
+}
+
+func init() {
+	operations.RegisterDriver(driver.Config{Name: "PlainLocal", OnlyLocal: true, NoCache: true, Hash: true}, func() driver.Driver {
+		return &plainLocal{}
+	})
+}
+
+func TestCopyFileVerify(t *testing.T) {
+	_, dst := setupCopy(t, "/copy_verify")
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	srcAccount, srcPath, _ := operations.GetAccountAndActualPath("/copy_verify_src/file.txt")
+	dstAccount, dstPath, _ := operations.GetAccountAndActualPath("/copy_verify_dst")
+	// the hashes of the file put by Local are known
+	err := operations.Put(context.Background(), srcAccount, filepath.Dir(srcPath), &model.FileStream{
+		Obj:        model.Object{Name: "file.txt", Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	srcFile, err := operations.Get(context.Background(), srcAccount, srcPath)
+	if err != nil || operations.HashOf(srcFile)[utils.MD5] == "" {
+		t.Fatalf("expected the hashes of src file, got %+v %+v", srcFile, err)
+	}
+
+	// the downloaded temp file is corrupted
+	tempFile := filepath.Join(conf.Conf.TempDir, "copy-verify")
+	corrupted := append([]byte{}, content...)
+	corrupted[5000] = 'x'
+	writeTemp := func() string {
+		if err := ioutil.WriteFile(tempFile, corrupted, 0600); err != nil {
+			t.Fatal(err)
+		}
+		checkpoint, _ := utils.Json.MarshalToString(copyCheckpoint{TempFile: tempFile})
+		return checkpoint
+	}
+	// Local puts the file by the hashes, the temp file isn't used
+	tsk := task.WithCancelCtx(&task.Task[uint64]{Name: "copy"})
+	tsk.SetCheckpoint(writeTemp())
+	if err = copyFileBetween2Accounts(tsk, srcAccount, dstAccount, srcPath, dstPath, ""); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dst, "file.txt")); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("expected the file rapid put, got %v", err)
+	}
+	if utils.Exists(tempFile) {
+		t.Errorf("temp file should be removed")
+	}
+
+	// it's found by comparing with the src hashes before put
+	plainDst := t.TempDir()
+	err = operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "PlainLocal",
+		VirtualPath: "/copy_verify_plain",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(plainDst)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create account: %+v", err)
+	}
+	dstAccount, dstPath, _ = operations.GetAccountAndActualPath("/copy_verify_plain")
+	tsk = task.WithCancelCtx(&task.Task[uint64]{Name: "copy"})
+	tsk.SetCheckpoint(writeTemp())
+	err = copyFileBetween2Accounts(tsk, srcAccount, dstAccount, srcPath, dstPath, "")
+	if !errors.Is(err, errs.VerifyFailed) {
+		t.Errorf("expected verify failed, but got %v", err)
+	}
+	if utils.Exists(filepath.Join(plainDst, "file.txt")) {
+		t.Errorf("the corrupted file shouldn't be put")
+	}
+}
@@ -0,0 +1,316 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	stdpath "path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/zip_crypto"
+	"github.com/pkg/errors"
+)
+
+var DecompressTaskManager = task.NewTaskManager(3, func(tid *uint64) {
+	atomic.AddUint64(tid, 1)
+})
+
+// conflict policies when the file to extract already exists
+const (
+	ConflictOverwrite = "overwrite"
+	ConflictSkip      = "skip"
+	ConflictRename    = "rename"
+)
+
+const decompressKind = "decompress"
+
+func init() {
+	DecompressTaskManager.RegisterResumer(decompressKind, resumeDecompress)
+}
+
+// decompressArgs is the persisted args of decompress tasks,
+// the password is kept in memory only
+type decompressArgs struct {
+	SrcPath   string `json:"src_path"`
+	DstPath   string `json:"dst_path"`
+	Password  string `json:"-"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+	Conflict  string `json:"conflict"`
+}
+
+type DecompressArgs struct {
+	// Password of encrypted zip
+	Password string
+	// Conflict policy, overwrite by default
+	Conflict string
+}
+
+type decompressor struct {
+	t         *task.Task[uint64]
+	account   driver.Driver
+	dstDir    string
+	password  string
+	conflict  string
+	extracted int
+}
+
+// decompress add a task to extract the archive to the dst dir
+func decompress(ctx context.Context, srcPath, dstDirPath string, args DecompressArgs) error {
+	if args.Conflict == "" {
+		args.Conflict = ConflictOverwrite
+	}
+	if !utils.SliceContains([]string{ConflictOverwrite, ConflictSkip, ConflictRename}, args.Conflict) {
+		return errors.Errorf("unknown conflict policy: %s", args.Conflict)
+	}
+	srcObj, err := get(ctx, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src object")
+	}
+	if srcObj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	format := decompressFormat(srcPath)
+	if format == "" {
+		return errors.Errorf("unsupported archive: %s", srcObj.GetName())
+	}
+	account, dstDirActualPath, err := operations.GetAccountAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst account")
+	}
+	if account.Config().NoUpload {
+		return errors.WithStack(errs.UploadNotSupported)
+	}
+	taskArgs := decompressArgs{
+		SrcPath:   srcPath,
+		DstPath:   dstDirPath,
+		Password:  args.Password,
+		Encrypted: args.Password != "",
+		Conflict:  args.Conflict,
+	}
+	argsStr, _ := utils.Json.MarshalToString(taskArgs)
+	DecompressTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		Name:    fmt.Sprintf("decompress %s to [%s](%s)", srcPath, account.GetAccount().VirtualPath, dstDirActualPath),
+		Kind:    decompressKind,
+		Args:    argsStr,
+		Func:    decompressFunc(taskArgs),
+		Creator: creator(ctx),
+	}))
+	return nil
+}
+
+func decompressFunc(args decompressArgs) task.Func[uint64] {
+	return func(t *task.Task[uint64]) error {
+		account, dstDirActualPath, err := operations.GetAccountAndActualPath(args.DstPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed get dst account")
+		}
+		srcObj, err := get(t.Ctx, args.SrcPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed get src object")
+		}
+		d := &decompressor{
+			t:        t,
+			account:  account,
+			dstDir:   dstDirActualPath,
+			password: args.Password,
+			conflict: args.Conflict,
+		}
+		switch format := decompressFormat(args.SrcPath); format {
+		case "zip":
+			err = d.zip(args.SrcPath, srcObj.GetSize())
+		default:
+			err = d.tar(args.SrcPath, srcObj.GetSize(), format == "tar.gz")
+		}
+		ClearCache(args.DstPath)
+		return err
+	}
+}
+
+func resumeDecompress(t *task.Task[uint64]) error {
+	var args decompressArgs
+	if err := utils.Json.UnmarshalFromString(t.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	// the password isn't persisted, the task has to be submitted again
+	if args.Encrypted {
+		return errors.New("password required")
+	}
+	t.Func = decompressFunc(args)
+	return nil
+}
+
+func decompressFormat(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+func (d *decompressor) zip(srcPath string, size int64) error {
+	d.t.SetStatus("reading the index of archive")
+	r, err := zip.NewReader(newRangeReaderAt(d.t.Ctx, srcPath, size), size)
+	if err != nil {
+		return errors.Wrapf(err, "failed read zip %s", srcPath)
+	}
+	for i, f := range r.File {
+		if utils.IsCanceled(d.t.Ctx) {
+			return nil
+		}
+		if strings.HasSuffix(f.Name, "/") || f.FileInfo().IsDir() {
+			err = d.makeDir(f.Name)
+		} else {
+			var rc io.ReadCloser
+			rc, err = zip_crypto.Open(f, d.password)
+			if err != nil {
+				return errors.WithMessagef(err, "failed open %s", f.Name)
+			}
+			err = d.put(f.Name, int64(f.UncompressedSize64), f.Modified, rc)
+		}
+		if err != nil {
+			return err
+		}
+		d.t.SetProgress((i + 1) * 100 / len(r.File))
+	}
+	d.t.SetStatus(fmt.Sprintf("extracted %d files", d.extracted))
+	return nil
+}
+
+// countReader count the bytes read from the archive to get the progress of tar
+type countReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (d *decompressor) tar(srcPath string, size int64, gz bool) error {
+	rc, err := OpenRange(d.t.Ctx, srcPath, 0, -1)
+	if err != nil {
+		return errors.WithMessagef(err, "failed open %s", srcPath)
+	}
+	defer rc.Close()
+	counter := &countReader{Reader: rc}
+	var r io.Reader = counter
+	if gz {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return errors.Wrapf(err, "failed read gzip %s", srcPath)
+		}
+		defer gr.Close()
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	for {
+		if utils.IsCanceled(d.t.Ctx) {
+			return nil
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed read tar %s", srcPath)
+		}
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			err = d.makeDir(hdr.Name)
+		case hdr.FileInfo().Mode().IsRegular():
+			err = d.put(hdr.Name, hdr.Size, hdr.ModTime, ioutil.NopCloser(tr))
+		}
+		if err != nil {
+			return err
+		}
+		if size > 0 {
+			d.t.SetProgress(int(counter.n * 100 / size))
+		}
+	}
+	d.t.SetProgress(100)
+	d.t.SetStatus(fmt.Sprintf("extracted %d files", d.extracted))
+	return nil
+}
+
+func (d *decompressor) makeDir(name string) error {
+	dirPath := stdpath.Join(d.dstDir, stdpath.Clean("/"+name))
+	if err := operations.MakeDir(d.t.Ctx, d.account, dirPath); err != nil {
+		return errors.WithMessagef(err, "failed make dir [%s]", dirPath)
+	}
+	return nil
+}
+
+// put upload the entry to the dst dir, the rc is closed
+func (d *decompressor) put(name string, size int64, modified time.Time, rc io.ReadCloser) error {
+	dstPath := stdpath.Join(d.dstDir, stdpath.Clean("/"+name))
+	dir, fileName := stdpath.Split(dstPath)
+	d.t.SetStatus(fmt.Sprintf("extracting %s", name))
+	if _, err := operations.Get(d.t.Ctx, d.account, dstPath); err == nil {
+		switch d.conflict {
+		case ConflictSkip:
+			_ = rc.Close()
+			return nil
+		case ConflictRename:
+			fileName, err = d.freeName(dir, fileName)
+			if err != nil {
+				_ = rc.Close()
+				return err
+			}
+		}
+	}
+	mimetype := mime.TypeByExtension(stdpath.Ext(fileName))
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+	stream := &model.FileStream{
+		Obj: model.Object{
+			Name:     fileName,
+			Size:     size,
+			Modified: modified,
+		},
+		ReadCloser: rc,
+		Mimetype:   mimetype,
+	}
+	err := operations.Put(d.t.Ctx, d.account, dir, stream, nil)
+	if err != nil {
+		return errors.WithMessagef(err, "failed put %s", name)
+	}
+	d.extracted++
+	return nil
+}
+
+// freeName find a name like `name (1).ext` which doesn't exist in the dir
+func (d *decompressor) freeName(dir, name string) (string, error) {
+	ext := stdpath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		newName := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		_, err := operations.Get(d.t.Ctx, d.account, stdpath.Join(dir, newName))
+		if errs.IsObjectNotFound(err) {
+			return newName, nil
+		}
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed check %s", newName)
+		}
+	}
+}
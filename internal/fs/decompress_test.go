@@ -0,0 +1,128 @@
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func writeTarGz(t *testing.T, path string) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	w := tar.NewWriter(gw)
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"} {
+		err := w.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(archiveFiles[name]))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(archiveFiles[name])
+	}
+	_ = w.Close()
+	_ = gw.Close()
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitDecompress(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if len(DecompressTaskManager.ListUndone()) == 0 {
+			for _, tsk := range DecompressTaskManager.ListDone() {
+				if tsk.GetState() != task.SUCCEEDED {
+					t.Fatalf("task %s is %s: %s", tsk.Name, tsk.GetState(), tsk.GetErrMsg())
+				}
+			}
+			DecompressTaskManager.RemoveAll()
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("decompress tasks timeout")
+}
+
+func TestDecompress(t *testing.T) {
+	root := t.TempDir()
+	writeZip(t, filepath.Join(root, "test.zip"))
+	writeTarGz(t, filepath.Join(root, "test.tar.gz"))
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "Local",
+		VirtualPath: "/decompress",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create account: %+v", err)
+	}
+	ctx := context.Background()
+	check := func(dir string, files map[string][]byte) {
+		for name, content := range files {
+			data, err := ioutil.ReadFile(filepath.Join(root, dir, name))
+			if err != nil || !bytes.Equal(data, content) {
+				t.Errorf("unexpected %s/%s: %v", dir, name, err)
+			}
+		}
+	}
+	for _, archive := range []string{"test.zip", "test.tar.gz"} {
+		dir := "out_" + archive
+		err := Decompress(ctx, "/decompress/"+archive, "/decompress/"+dir, DecompressArgs{})
+		if err != nil {
+			t.Fatalf("failed to decompress %s: %+v", archive, err)
+		}
+		waitDecompress(t)
+		check(dir, archiveFiles)
+	}
+
+	// conflict
+	dir := filepath.Join(root, "out_test.zip")
+	_ = ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("modified"), 0644)
+	ClearCache("/decompress/out_test.zip")
+	err = Decompress(ctx, "/decompress/test.zip", "/decompress/out_test.zip", DecompressArgs{Conflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("failed to decompress: %+v", err)
+	}
+	waitDecompress(t)
+	check("out_test.zip", map[string][]byte{"a.txt": []byte("modified")})
+	err = Decompress(ctx, "/decompress/test.zip", "/decompress/out_test.zip", DecompressArgs{Conflict: ConflictRename})
+	if err != nil {
+		t.Fatalf("failed to decompress: %+v", err)
+	}
+	waitDecompress(t)
+	check("out_test.zip", map[string][]byte{
+		"a.txt":             []byte("modified"),
+		"a (1).txt":         archiveFiles["a.txt"],
+		"dir/sub/c (1).txt": archiveFiles["dir/sub/c.txt"],
+	})
+	err = Decompress(ctx, "/decompress/test.zip", "/decompress/out_test.zip", DecompressArgs{Conflict: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("failed to decompress: %+v", err)
+	}
+	waitDecompress(t)
+	check("out_test.zip", archiveFiles)
+
+	if err := Decompress(ctx, "/decompress/test.zip", "/decompress/out", DecompressArgs{Conflict: "unknown"}); err == nil {
+		t.Errorf("expect error of unknown conflict policy")
+	}
+}
+
+func TestResumeDecompressWithPassword(t *testing.T) {
+	args := decompressArgs{SrcPath: "/a.zip", DstPath: "/out", Password: "secret", Encrypted: true}
+	argsStr, _ := utils.Json.MarshalToString(args)
+	if strings.Contains(argsStr, "secret") {
+		t.Fatalf("password is persisted: %s", argsStr)
+	}
+	err := resumeDecompress(&task.Task[uint64]{Args: argsStr})
+	if err == nil || !strings.Contains(err.Error(), "password required") {
+		t.Errorf("expect error of password required, got %+v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
 	log "github.com/sirupsen/logrus"
+	stdpath "path"
 )
 
 // the param named path of functions in this package is a virtual path
@@ -56,13 +57,22 @@ func Move(ctx context.Context, srcPath, dstDirPath string) error {
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string) (bool, error) {
-	res, err := _copy(ctx, srcObjPath, dstDirPath)
+	res, err := _copy(ctx, srcObjPath, dstDirPath, stdpath.Base(srcObjPath))
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
 	return res, err
 }
 
+// CopyAs is Copy but the copied object is named dstName
+func CopyAs(ctx context.Context, srcObjPath, dstDirPath, dstName string) (bool, error) {
+	res, err := _copy(ctx, srcObjPath, dstDirPath, dstName)
+	if err != nil {
+		log.Errorf("failed copy %s to %s as %s: %+v", srcObjPath, dstDirPath, dstName, err)
+	}
+	return res, err
+}
+
 func Rename(ctx context.Context, srcPath, dstName string) error {
 	err := rename(ctx, srcPath, dstName)
 	if err != nil {
@@ -87,14 +97,31 @@ func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	return err
 }
 
-func PutAsTask(dstDirPath string, file model.FileStreamer) error {
-	err := putAsTask(dstDirPath, file)
+func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) error {
+	err := putAsTask(ctx, dstDirPath, file)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
 	return err
 }
 
+func Decompress(ctx context.Context, srcPath, dstDirPath string, args DecompressArgs) error {
+	err := decompress(ctx, srcPath, dstDirPath, args)
+	if err != nil {
+		log.Errorf("failed decompress %s to %s: %+v", srcPath, dstDirPath, err)
+	}
+	return err
+}
+
+// Sync submit a task to sync the files from srcPath to dstPath by the mode
+func Sync(ctx context.Context, srcPath, dstPath, mode string) (uint64, error) {
+	tid, err := syncDirs(ctx, srcPath, dstPath, mode)
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcPath, dstPath, err)
+	}
+	return tid, err
+}
+
 func GetAccount(path string) (driver.Driver, error) {
 	accountDriver, _, err := operations.GetAccountAndActualPath(path)
 	if err != nil {
@@ -34,5 +34,12 @@ func get(ctx context.Context, path string) (model.Obj, error) {
 		}
 		return nil, errors.WithMessage(err, "failed get account")
 	}
-	return operations.Get(ctx, account, actualPath)
+	obj, err := operations.Get(ctx, account, actualPath)
+	if err != nil {
+		// maybe an entry in an archive
+		if archivePath, archive, innerPath, ok := findArchive(ctx, path); ok && innerPath != "/" {
+			return getArchiveEntry(ctx, archivePath, archive, innerPath)
+		}
+	}
+	return obj, err
 }
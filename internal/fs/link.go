@@ -12,5 +12,12 @@ func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get account")
 	}
-	return operations.Link(ctx, account, actualPath, args)
+	l, obj, err := operations.Link(ctx, account, actualPath, args)
+	if err != nil {
+		// maybe an entry in an archive
+		if archivePath, archive, innerPath, ok := findArchive(ctx, path); ok && innerPath != "/" {
+			return linkArchive(ctx, archivePath, archive, innerPath, args)
+		}
+	}
+	return l, obj, err
 }
@@ -24,6 +24,12 @@ func list(ctx context.Context, path string) ([]model.Obj, error) {
 		return nil, errors.WithMessage(err, "failed get account")
 	}
 	objs, err := operations.List(ctx, account, actualPath)
+	if err != nil {
+		// maybe an archive or a dir in an archive
+		if archivePath, archive, innerPath, ok := findArchive(ctx, path); ok {
+			objs, err = listArchive(ctx, archivePath, archive, innerPath)
+		}
+	}
 	if err != nil {
 		log.Errorf("%+v", err)
 		if len(virtualFiles) != 0 {
@@ -47,6 +53,14 @@ func list(ctx context.Context, path string) ([]model.Obj, error) {
 	return objs, nil
 }
 
+// Hide filter the objs of the path hidden from the user by the meta
+func Hide(user *model.User, meta *model.Meta, path string, objs []model.Obj) []model.Obj {
+	if whetherHide(user, meta, path) {
+		return hide(objs, meta)
+	}
+	return objs
+}
+
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is admin, don't hide
 	if user.CanSeeHides() {
@@ -3,21 +3,41 @@ package fs
 import (
 	"context"
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
+	"os"
 	"sync/atomic"
+	"time"
 )
 
 var UploadTaskManager = task.NewTaskManager[uint64](3, func(tid *uint64) {
 	atomic.AddUint64(tid, 1)
 })
 
+const uploadKind = "upload"
+
+func init() {
+	UploadTaskManager.SetRetryPolicy(task.DefaultRetryPolicy)
+	UploadTaskManager.RegisterResumer(uploadKind, resumeUpload)
+}
+
+// uploadArgs is the persisted args of upload tasks
+type uploadArgs struct {
+	Account  string `json:"account"`
+	DstPath  string `json:"dst_path"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Mimetype string `json:"mimetype"`
+	TempFile string `json:"temp_file"`
+}
+
 // putAsTask add as a put task and return immediately
-func putAsTask(dstDirPath string, file model.FileStreamer) error {
+func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) error {
 	account, dstDirActualPath, err := operations.GetAccountAndActualPath(dstDirPath)
 	if account.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
@@ -32,15 +52,89 @@ func putAsTask(dstDirPath string, file model.FileStreamer) error {
 		}
 		file.SetReadCloser(tempFile)
 	}
+	args := uploadArgs{
+		Account:  account.GetAccount().VirtualPath,
+		DstPath:  dstDirActualPath,
+		Name:     file.GetName(),
+		Size:     file.GetSize(),
+		Mimetype: file.GetMimetype(),
+	}
+	if f, ok := file.GetReadCloser().(*os.File); ok {
+		args.TempFile = f.Name()
+	}
+	argsStr, _ := utils.Json.MarshalToString(args)
+	f := func(task *task.Task[uint64]) error {
+		return operations.Put(task.Ctx, account, dstDirActualPath, file, nil)
+	}
+	if args.TempFile != "" {
+		// open the temp file every time, so that it can be paused and resumed
+		_ = file.Close()
+		f = uploadFunc(account, args)
+	}
 	UploadTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-		Name: fmt.Sprintf("upload %s to [%s](%s)", file.GetName(), account.GetAccount().VirtualPath, dstDirActualPath),
-		Func: func(task *task.Task[uint64]) error {
-			return operations.Put(task.Ctx, account, dstDirActualPath, file, nil)
-		},
+		Name:    fmt.Sprintf("upload %s to [%s](%s)", file.GetName(), account.GetAccount().VirtualPath, dstDirActualPath),
+		Kind:    uploadKind,
+		Args:    argsStr,
+		Func:    f,
+		Creator: creator(ctx),
 	}))
 	return nil
 }
 
+func uploadFunc(account driver.Driver, args uploadArgs) task.Func[uint64] {
+	return func(t *task.Task[uint64]) error {
+		f, err := os.Open(args.TempFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed open temp file")
+		}
+		stream := &model.FileStream{
+			Obj: model.Object{
+				Name:     args.Name,
+				Size:     args.Size,
+				Modified: time.Now(),
+			},
+			ReadCloser: f,
+			Mimetype:   args.Mimetype,
+		}
+		return operations.Put(t.Ctx, account, args.DstPath, stream, nil)
+	}
+}
+
+// resumeUpload upload the temp file again, the upload without temp file can't be resumed
+func resumeUpload(t *task.Task[uint64]) error {
+	var args uploadArgs
+	if err := utils.Json.UnmarshalFromString(t.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	if args.TempFile == "" {
+		return errors.New("no temp file to upload")
+	}
+	account, err := operations.GetAccountByVirtualPath(args.Account)
+	if err != nil {
+		return errors.WithMessage(err, "failed get account")
+	}
+	t.Func = uploadFunc(account, args)
+	return nil
+}
+
+// TempFilesInUse return the temp files of undone upload and copy tasks, which shouldn't be cleared
+func TempFilesInUse() []string {
+	var files []string
+	for _, t := range UploadTaskManager.ListUndone() {
+		var args uploadArgs
+		if err := utils.Json.UnmarshalFromString(t.Args, &args); err == nil && args.TempFile != "" {
+			files = append(files, args.TempFile)
+		}
+	}
+	for _, t := range CopyTaskManager.ListUndone() {
+		var checkpoint copyCheckpoint
+		if err := utils.Json.UnmarshalFromString(t.GetCheckpoint(), &checkpoint); err == nil && checkpoint.TempFile != "" {
+			files = append(files, checkpoint.TempFile)
+		}
+	}
+	return files
+}
+
 // putDirect put the file and return after finish
 func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer) error {
 	account, dstDirActualPath, err := operations.GetAccountAndActualPath(dstDirPath)
@@ -0,0 +1,159 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// OpenRange read length bytes of the file from offset, length < 0 means to the end.
+// the Range header is passed to the link, so that only the needed bytes are transferred
+// if the link supports range, otherwise the leading bytes are skipped
+func OpenRange(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	link, _, err := Link(ctx, path, model.LinkArgs{Header: rangeHeader(offset, length)})
+	if err != nil {
+		return nil, err
+	}
+	return openLinkRange(ctx, link, offset, length)
+}
+
+func rangeHeader(offset, length int64) http.Header {
+	r := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		r += fmt.Sprint(offset + length - 1)
+	}
+	return http.Header{"Range": []string{r}}
+}
+
+// openLinkRange read the range of the link, the link should be got with the rangeHeader
+func openLinkRange(ctx context.Context, link *model.Link, offset, length int64) (io.ReadCloser, error) {
+	if link.RangeReader != nil {
+		if link.Data != nil {
+			_ = link.Data.Close()
+		}
+		return link.RangeReader.RangeRead(ctx, offset, length)
+	}
+	var rc io.ReadCloser
+	partial := false
+	switch {
+	case link.Data != nil:
+		rc, partial = link.Data, link.Status == http.StatusPartialContent
+	case link.FilePath != nil:
+		f, err := os.Open(*link.FilePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while open %s", *link.FilePath)
+		}
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrapf(err, "error while seek %s", *link.FilePath)
+		}
+		rc, partial = f, true
+	default:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for h, val := range link.Header {
+			req.Header[h] = val
+		}
+		req.Header.Set("Range", rangeHeader(offset, length).Get("Range"))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while request %s", link.URL)
+		}
+		if res.StatusCode >= 400 {
+			_ = res.Body.Close()
+			return nil, errors.Errorf("error while request %s: %s", link.URL, res.Status)
+		}
+		rc, partial = res.Body, res.StatusCode == http.StatusPartialContent
+	}
+	if !partial {
+		// the range is not supported, skip the leading bytes
+		if _, err := io.CopyN(ioutil.Discard, rc, offset); err != nil {
+			_ = rc.Close()
+			return nil, errors.Wrapf(err, "error while skip to %d", offset)
+		}
+	}
+	if length >= 0 {
+		rc = utils.ReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+	}
+	return rc, nil
+}
+
+const readAheadSize = 64 * 1024
+
+// rangeReaderAt implement io.ReaderAt of a file by OpenRange,
+// the last block read is kept because small adjacent reads are common
+type rangeReaderAt struct {
+	ctx  context.Context
+	path string
+	size int64
+
+	mu  sync.Mutex
+	off int64
+	buf []byte
+	// bind is held while the reader is bound to the ctx of a caller
+	bind sync.Mutex
+}
+
+func newRangeReaderAt(ctx context.Context, path string, size int64) *rangeReaderAt {
+	return &rangeReaderAt{ctx: ctx, path: path, size: size}
+}
+
+// with call fn while the reader reads with ctx, it's for the reader kept for later
+// reads, such as the one of the files of zip to get their data offset
+func (r *rangeReaderAt) with(ctx context.Context, fn func() error) error {
+	r.bind.Lock()
+	defer r.bind.Unlock()
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	return fn()
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for n < len(p) && off < r.size {
+		if off >= r.off && off < r.off+int64(len(r.buf)) {
+			c := copy(p[n:], r.buf[off-r.off:])
+			n += c
+			off += int64(c)
+			continue
+		}
+		length := int64(len(p) - n)
+		if length < readAheadSize {
+			length = readAheadSize
+		}
+		if off+length > r.size {
+			length = r.size - off
+		}
+		rc, err := OpenRange(r.ctx, r.path, off, length)
+		if err != nil {
+			return n, err
+		}
+		buf := make([]byte, length)
+		_, err = io.ReadFull(rc, buf)
+		_ = rc.Close()
+		if err != nil {
+			return n, errors.Wrapf(err, "error while read %s at %d", r.path, off)
+		}
+		r.off, r.buf = off, buf
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,246 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const syncKind = "sync"
+
+func init() {
+	CopyTaskManager.RegisterResumer(syncKind, resumeSync)
+}
+
+// syncArgs is the persisted args of sync tasks, the paths are virtual paths
+type syncArgs struct {
+	SrcPath string `json:"src_path"`
+	DstPath string `json:"dst_path"`
+	Mode    string `json:"mode"`
+}
+
+// syncDirs submit a group task to CopyTaskManager, it compares the two paths and
+// submits the different files as its copy file children
+func syncDirs(ctx context.Context, srcPath, dstPath, mode string) (uint64, error) {
+	switch mode {
+	case model.SyncCopyNew, model.SyncMirror, model.SyncTwoWay:
+	default:
+		return 0, errors.Errorf("unknown sync mode: %s", mode)
+	}
+	args := syncArgs{
+		SrcPath: utils.StandardizePath(srcPath),
+		DstPath: utils.StandardizePath(dstPath),
+		Mode:    mode,
+	}
+	if utils.IsSubPath(args.SrcPath, args.DstPath) || utils.IsSubPath(args.DstPath, args.SrcPath) {
+		return 0, errors.New("src and dst can't be the same or contain each other")
+	}
+	argsStr, err := utils.Json.MarshalToString(args)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		Name:    fmt.Sprintf("sync %s to %s (%s)", args.SrcPath, args.DstPath, mode),
+		Kind:    syncKind,
+		Args:    argsStr,
+		Func:    syncFunc(args),
+		Group:   true,
+		Creator: creator(ctx),
+	})), nil
+}
+
+func syncFunc(args syncArgs) task.Func[uint64] {
+	return func(t *task.Task[uint64]) error {
+		srcAccount, srcActualPath, err := operations.GetAccountAndActualPath(args.SrcPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed get src account")
+		}
+		dstAccount, dstActualPath, err := operations.GetAccountAndActualPath(args.DstPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed get dst account")
+		}
+		s := &syncer{
+			tsk:        t,
+			mode:       args.Mode,
+			srcAccount: srcAccount,
+			dstAccount: dstAccount,
+			submitted:  make(map[string]struct{}),
+		}
+		// the children submitted before the restart are not submitted again
+		for _, child := range t.GetChildren() {
+			s.submitted[child.Args] = struct{}{}
+		}
+		// the dirs not existing are empty only if they are nested, or a typo of
+		// the src would make mirror remove everything in dst
+		if err := isDir(t.Ctx, srcAccount, srcActualPath); err != nil {
+			return errors.WithMessagef(err, "failed get src [%s]", args.SrcPath)
+		}
+		if err := isDir(t.Ctx, dstAccount, dstActualPath); err != nil {
+			return errors.WithMessagef(err, "failed get dst [%s]", args.DstPath)
+		}
+		t.SetStatus("comparing")
+		if err := s.sync(srcActualPath, dstActualPath); err != nil {
+			return err
+		}
+		t.SetStatus(fmt.Sprintf("%d files to copy, %d objs removed", s.copied, s.removed))
+		return nil
+	}
+}
+
+// isDir check the dir exists
+func isDir(ctx context.Context, account driver.Driver, path string) error {
+	obj, err := operations.Get(ctx, account, path)
+	if err != nil {
+		return err
+	}
+	if !obj.IsDir() {
+		return errors.WithStack(errs.NotFolder)
+	}
+	return nil
+}
+
+func resumeSync(t *task.Task[uint64]) error {
+	var args syncArgs
+	if err := utils.Json.UnmarshalFromString(t.Args, &args); err != nil {
+		return errors.Wrap(err, "failed unmarshal args")
+	}
+	t.Func = syncFunc(args)
+	return nil
+}
+
+type syncer struct {
+	tsk                    *task.Task[uint64]
+	mode                   string
+	srcAccount, dstAccount driver.Driver
+	submitted              map[string]struct{}
+	copied, removed        int
+}
+
+// sync compare the objs of the two dirs recursively
+func (s *syncer) sync(srcDir, dstDir string) error {
+	if utils.IsCanceled(s.tsk.Ctx) {
+		return s.tsk.Ctx.Err()
+	}
+	srcObjs, err := s.list(s.srcAccount, srcDir)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s]", srcDir)
+	}
+	dstObjs, err := s.list(s.dstAccount, dstDir)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list dst [%s]", dstDir)
+	}
+	for name, srcObj := range srcObjs {
+		srcPath, dstPath := stdpath.Join(srcDir, name), stdpath.Join(dstDir, name)
+		dstObj, ok := dstObjs[name]
+		if ok && dstObj.IsDir() != srcObj.IsDir() {
+			// the file replaces the dir with the same name or vice versa
+			if s.mode != model.SyncMirror {
+				continue
+			}
+			if err := s.remove(dstPath); err != nil {
+				return err
+			}
+			ok = false
+		}
+		if srcObj.IsDir() {
+			if err := s.sync(srcPath, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case !ok || (s.mode != model.SyncTwoWay && changed(srcObj, dstObj, true)):
+			s.copy(s.srcAccount, s.dstAccount, srcPath, dstDir, srcObj)
+		case s.mode == model.SyncTwoWay && changed(srcObj, dstObj, false):
+			// the one modified later wins
+			if dstObj.ModTime().After(srcObj.ModTime()) {
+				s.copy(s.dstAccount, s.srcAccount, dstPath, srcDir, dstObj)
+			} else {
+				s.copy(s.srcAccount, s.dstAccount, srcPath, dstDir, srcObj)
+			}
+		}
+	}
+	for name, dstObj := range dstObjs {
+		if _, ok := srcObjs[name]; ok {
+			continue
+		}
+		srcPath, dstPath := stdpath.Join(srcDir, name), stdpath.Join(dstDir, name)
+		switch s.mode {
+		case model.SyncMirror:
+			if err := s.remove(dstPath); err != nil {
+				return err
+			}
+		case model.SyncTwoWay:
+			// the deleted objs can't be told from the new ones, so they are copied back
+			if dstObj.IsDir() {
+				if err := s.syncBack(dstPath, srcPath); err != nil {
+					return err
+				}
+			} else {
+				s.copy(s.dstAccount, s.srcAccount, dstPath, srcDir, dstObj)
+			}
+		}
+	}
+	return nil
+}
+
+// syncBack copy the dir only in dst to src
+func (s *syncer) syncBack(dstDir, srcDir string) error {
+	s.srcAccount, s.dstAccount = s.dstAccount, s.srcAccount
+	defer func() {
+		s.srcAccount, s.dstAccount = s.dstAccount, s.srcAccount
+	}()
+	return s.sync(dstDir, srcDir)
+}
+
+// list return the objs of the dir by name, the nested dir not existing is empty
+func (s *syncer) list(account driver.Driver, dir string) (map[string]model.Obj, error) {
+	objs, err := operations.List(s.tsk.Ctx, account, dir, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+	}
+	return m, nil
+}
+
+func (s *syncer) copy(srcAccount, dstAccount driver.Driver, srcPath, dstDir string, obj model.Obj) {
+	child := newCopyTask(copyFileKind, srcAccount, dstAccount, srcPath, dstDir, "", obj.GetSize())
+	s.copied++
+	if _, ok := s.submitted[child.Args]; ok {
+		return
+	}
+	s.submitted[child.Args] = struct{}{}
+	CopyTaskManager.SubmitChild(s.tsk, child)
+}
+
+func (s *syncer) remove(path string) error {
+	if err := operations.Remove(s.tsk.Ctx, s.dstAccount, path); err != nil {
+		return errors.WithMessagef(err, "failed remove [%s]", path)
+	}
+	s.removed++
+	return nil
+}
+
+// changed check whether the file src is different from dst, they are compared by hash
+// if both of them have, otherwise by size and, if byTime, whether src is modified later.
+// the two-way sync can't compare by time, since the copied file is always newer
+func changed(src, dst model.Obj, byTime bool) bool {
+	if equal, compared := operations.HashOf(src).Equal(operations.HashOf(dst)); compared {
+		return !equal
+	}
+	return src.GetSize() != dst.GetSize() || (byTime && src.ModTime().After(dst.ModTime()))
+}
@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitSync(t *testing.T, ctx context.Context, mode string) {
+	tid, err := Sync(ctx, "/sync_src", "/sync_dst", mode)
+	if err != nil {
+		t.Fatalf("failed to sync: %+v", err)
+	}
+	tsk := CopyTaskManager.MustGet(tid)
+	for i := 0; i < 100 && tsk.GetState() != task.SUCCEEDED; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if tsk.GetState() != task.SUCCEEDED {
+		t.Fatalf("sync task is %s: %s", tsk.GetState(), tsk.GetErrMsg())
+	}
+}
+
+func TestSync(t *testing.T) {
+	src, dst := setupCopy(t, "/sync")
+	ctx := context.Background()
+	writeFiles(t, src, map[string]string{"a.txt": "a", "dir/b.txt": "bb"})
+	writeFiles(t, dst, map[string]string{"dir/b.txt": "b", "extra.txt": "extra", "only_dst/c.txt": "c"})
+
+	waitSync(t, ctx, model.SyncCopyNew)
+	for name, content := range map[string]string{"a.txt": "a", "dir/b.txt": "bb", "extra.txt": "extra"} {
+		if data, err := ioutil.ReadFile(filepath.Join(dst, name)); err != nil || string(data) != content {
+			t.Errorf("unexpected %s after copy new: %s, %v", name, data, err)
+		}
+	}
+
+	waitSync(t, ctx, model.SyncTwoWay)
+	if data, err := ioutil.ReadFile(filepath.Join(src, "only_dst", "c.txt")); err != nil || string(data) != "c" {
+		t.Errorf("the file only in dst should be copied back: %s, %v", data, err)
+	}
+
+	_ = os.Remove(filepath.Join(src, "extra.txt"))
+	_ = os.RemoveAll(filepath.Join(src, "only_dst"))
+	waitSync(t, ctx, model.SyncMirror)
+	for _, name := range []string{"extra.txt", "only_dst"} {
+		if utils.Exists(filepath.Join(dst, name)) {
+			t.Errorf("%s should be removed by mirror", name)
+		}
+	}
+	CopyTaskManager.RemoveAll()
+}
+
+func TestSyncMissingSrc(t *testing.T) {
+	_, dst := setupCopy(t, "/sync_missing")
+	writeFiles(t, dst, map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	tid, err := Sync(context.Background(), "/sync_missing_src/typo", "/sync_missing_dst", model.SyncMirror)
+	if err != nil {
+		t.Fatalf("failed to sync: %+v", err)
+	}
+	tsk := CopyTaskManager.MustGet(tid)
+	for i := 0; i < 100 && tsk.GetState() != task.ERRORED; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if tsk.GetState() != task.ERRORED {
+		t.Fatalf("sync from missing src should fail, but it's %s", tsk.GetState())
+	}
+	for _, name := range []string{"a.txt", "dir/b.txt"} {
+		if !utils.Exists(filepath.Join(dst, name)) {
+			t.Errorf("%s should be untouched", name)
+		}
+	}
+	if _, err := Sync(context.Background(), "/sync_missing_src", "/sync_missing_src/dir", model.SyncMirror); err == nil {
+		t.Errorf("sync to the dir inside src should be rejected")
+	}
+	CopyTaskManager.RemoveAll()
+}
@@ -1,16 +1,11 @@
 package fs
 
 import (
+	"context"
+
 	"github.com/alist-org/alist/v3/internal/operations"
-	"io"
-	"mime"
-	"net/http"
-	"os"
-	stdpath "path"
-	"strings"
 
 	"github.com/alist-org/alist/v3/internal/model"
-	"github.com/pkg/errors"
 )
 
 func ClearCache(path string) {
@@ -30,45 +25,10 @@ func containsByName(files []model.Obj, file model.Obj) bool {
 	return false
 }
 
-var httpClient = &http.Client{}
-
-func getFileStreamFromLink(file model.Obj, link *model.Link) (model.FileStreamer, error) {
-	var rc io.ReadCloser
-	mimetype := mime.TypeByExtension(stdpath.Ext(file.GetName()))
-	if link.Data != nil {
-		rc = link.Data
-	} else if link.FilePath != nil {
-		f, err := os.Open(*link.FilePath)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to open file %s", *link.FilePath)
-		}
-		rc = f
-	} else {
-		req, err := http.NewRequest(http.MethodGet, link.URL, nil)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to create request for %s", link.URL)
-		}
-		for h, val := range link.Header {
-			req.Header[h] = val
-		}
-		res, err := httpClient.Do(req)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get response for %s", link.URL)
-		}
-		mt := res.Header.Get("Content-Type")
-		if mt != "" && strings.ToLower(mt) != "application/octet-stream" {
-			mimetype = mt
-		}
-		rc = res.Body
-	}
-	// if can't get mimetype, use default application/octet-stream
-	if mimetype == "" {
-		mimetype = "application/octet-stream"
-	}
-	stream := &model.FileStream{
-		Obj:        file,
-		ReadCloser: rc,
-		Mimetype:   mimetype,
+// creator return the name of the user in the ctx, who creates the task
+func creator(ctx context.Context) string {
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		return user.Username
 	}
-	return stream, nil
+	return ""
 }
@@ -0,0 +1,116 @@
+package message
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+// types of events
+const (
+	EventTask    = "task"
+	EventAria2   = "aria2"
+	EventMessage = "message"
+)
+
+// Event is pushed to the subscribers by SSE or WebSocket
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	// the name of the user the event belongs to, empty means only admins can see it
+	user string
+}
+
+// TaskData is the data of task events
+type TaskData struct {
+	Manager  string `json:"manager"`
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Error    string `json:"error"`
+}
+
+// Aria2Data is the data of aria2 events
+type Aria2Data struct {
+	Gid    string `json:"gid"`
+	Status string `json:"status"`
+}
+
+type subscriber struct {
+	user *model.User
+	c    chan Event
+}
+
+func (s *subscriber) canSee(e Event) bool {
+	return s.user.IsAdmin() || (e.user != "" && e.user == s.user.Username)
+}
+
+// Hub dispatch the events to the subscribers
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func (h *Hub) Subscribe(user *model.User) *subscriber {
+	s := &subscriber{user: user, c: make(chan Event, 64)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, s)
+}
+
+// Publish send the event to the subscribers who can see it, the event is dropped
+// for the subscriber which is too slow. it returns whether anyone received it
+func (h *Hub) Publish(e Event) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	received := false
+	for s := range h.subscribers {
+		if !s.canSee(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+			received = true
+		default:
+		}
+	}
+	return received
+}
+
+// PublishTask publish the change of the task in the manager, it's seen by its creator
+func PublishTask[K comparable](manager string, t *task.Task[K]) {
+	data := TaskData{
+		Manager:  manager,
+		ID:       fmt.Sprint(t.ID),
+		Name:     t.Name,
+		State:    t.GetState(),
+		Status:   t.GetStatus(),
+		Progress: t.GetProgress(),
+		Error:    t.GetErrMsg(),
+	}
+	if parent := t.GetParent(); parent != nil {
+		data.ParentID = fmt.Sprint(parent.ID)
+	}
+	HubInstance.Publish(Event{Type: EventTask, Data: data, user: t.Creator})
+}
+
+// PublishAria2 publish the event of aria2, user is the creator of the download task
+func PublishAria2(gid, status, user string) {
+	HubInstance.Publish(Event{Type: EventAria2, Data: Aria2Data{Gid: gid, Status: status}, user: user})
+}
+
+var HubInstance = &Hub{
+	subscribers: map[*subscriber]struct{}{},
+}
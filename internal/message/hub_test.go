@@ -0,0 +1,28 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestHub_Publish(t *testing.T) {
+	h := &Hub{subscribers: map[*subscriber]struct{}{}}
+	admin := h.Subscribe(&model.User{Username: "admin", Role: model.ADMIN})
+	foo := h.Subscribe(&model.User{Username: "foo", Role: model.GENERAL})
+	bar := h.Subscribe(&model.User{Username: "bar", Role: model.GENERAL})
+	if !h.Publish(Event{Type: EventTask, user: "foo"}) {
+		t.Fatalf("event of foo should be received")
+	}
+	if len(admin.c) != 1 || len(foo.c) != 1 || len(bar.c) != 0 {
+		t.Errorf("event of foo should be received by admin and foo only")
+	}
+	h.Publish(Event{Type: EventMessage})
+	if len(admin.c) != 2 || len(foo.c) != 1 || len(bar.c) != 0 {
+		t.Errorf("event without user should be received by admin only")
+	}
+	h.Unsubscribe(admin)
+	if h.Publish(Event{Type: EventMessage}) {
+		t.Errorf("event without user should not be received after admin unsubscribe")
+	}
+}
@@ -39,11 +39,17 @@ func (p *Post) SendHandle(c *gin.Context) {
 	}
 }
 
+// Send the message to web, it's pushed to the subscribers of the hub
+// and sent to the polling client as well, it fails if neither received it
 func (p *Post) Send(data interface{}) error {
+	published := HubInstance.Publish(Event{Type: EventMessage, Data: data})
 	select {
 	case p.ToSend <- data:
 		return nil
 	default:
+		if published {
+			return nil
+		}
 		return errors.New("send failed")
 	}
 }
@@ -57,11 +63,17 @@ func (p *Post) Receive() (string, error) {
 	}
 }
 
+// WaitSend is Send but waits for the polling client until timeout,
+// it doesn't time out if the subscribers of the hub received the message
 func (p *Post) WaitSend(data interface{}, d int) error {
+	published := HubInstance.Publish(Event{Type: EventMessage, Data: data})
 	select {
 	case p.ToSend <- data:
 		return nil
 	case <-time.After(time.Duration(d) * time.Second):
+		if published {
+			return nil
+		}
 		return errors.New("send timeout")
 	}
 }
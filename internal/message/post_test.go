@@ -0,0 +1,33 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestPost_WaitSend(t *testing.T) {
+	p := &Post{ToSend: make(chan interface{})}
+	if err := p.Send("nobody"); err == nil {
+		t.Errorf("expected send failed without receivers")
+	}
+	admin := HubInstance.Subscribe(&model.User{Username: "admin", Role: model.ADMIN})
+	defer HubInstance.Unsubscribe(admin)
+	if err := p.Send("hub"); err != nil {
+		t.Errorf("expected sent to the hub, got %+v", err)
+	}
+	// the polling client gets the message which the hub has pushed too
+	polled := make(chan interface{})
+	go func() {
+		polled <- <-p.ToSend
+	}()
+	if err := p.WaitSend("both", 5); err != nil {
+		t.Fatalf("failed to send: %+v", err)
+	}
+	if data := <-polled; data != "both" {
+		t.Errorf("expected the polling client got the message, got %v", data)
+	}
+	if len(admin.c) != 2 {
+		t.Errorf("expected 2 events pushed to the hub, got %d", len(admin.c))
+	}
+}
@@ -0,0 +1,33 @@
+package message
+
+import (
+	"io"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+const keepAliveInterval = 30 * time.Second
+
+// SSEHandle push the events to the user by Server-Sent Events
+func (h *Hub) SSEHandle(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	s := h.Subscribe(user)
+	defer h.Unsubscribe(s)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e := <-s.c:
+			c.SSEvent(e.Type, e.Data)
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
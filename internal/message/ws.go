@@ -1,3 +1,65 @@
 package message
 
-// TODO websocket implementation
+import (
+	"net/http"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+var upgrader = websocket.Upgrader{
+	// the cors is allowed for all origins
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// WsHandle push the events to the user by WebSocket, the text messages
+// from admins are received by the Messenger
+func (h *Hub) WsHandle(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Errorf("failed upgrade websocket: %+v", err)
+		return
+	}
+	defer conn.Close()
+	s := h.Subscribe(user)
+	defer h.Unsubscribe(s)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if typ != websocket.TextMessage || !user.IsAdmin() {
+				continue
+			}
+			select {
+			case PostInstance.Received <- string(data):
+			default:
+				log.Warnf("message dropped since no one is receiving: %s", data)
+			}
+		}
+	}()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e := <-s.c:
+			err = conn.WriteJSON(e)
+		case <-keepAlive.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		case <-closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -13,6 +13,7 @@ type Account struct {
 	Modified    time.Time `json:"modified"`
 	Sort
 	Proxy
+	Limit
 }
 
 type Sort struct {
@@ -27,6 +28,12 @@ type Proxy struct {
 	DownProxyUrl string `json:"down_proxy_url"`
 }
 
+// Limit is the bandwidth of the account in KiB/s, 0 is unlimited
+type Limit struct {
+	DownLimit int64 `json:"down_limit"`
+	UpLimit   int64 `json:"up_limit"`
+}
+
 func (a Account) GetAccount() Account {
 	return a
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"time"
@@ -12,10 +13,23 @@ type LinkArgs struct {
 }
 
 type Link struct {
-	URL        string         `json:"url"`
-	Header     http.Header    `json:"header"` // needed header
-	Data       io.ReadCloser  // return file reader directly
-	Status     int            // status maybe 200 or 206, etc
-	FilePath   *string        // local file, return the filepath
-	Expiration *time.Duration // url expiration time
+	URL         string         `json:"url"`
+	Header      http.Header    `json:"header"` // needed header
+	Data        io.ReadCloser  // return file reader directly
+	Status      int            // status maybe 200 or 206, etc
+	FilePath    *string        // local file, return the filepath
+	Expiration  *time.Duration // url expiration time
+	RangeReader RangeReader    `json:"-"` // read any range of the file, it's preferred to Data
+}
+
+// RangeReader read length bytes of the file from offset, length < 0 means to the end.
+// it can be called many times, so that the file can be served by ranges
+type RangeReader interface {
+	RangeRead(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+}
+
+type RangeReaderFunc func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+
+func (f RangeReaderFunc) RangeRead(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	return f(ctx, offset, length)
 }
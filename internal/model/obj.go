@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Obj interface {
@@ -32,6 +34,12 @@ type Thumbnail interface {
 	Thumbnail() string
 }
 
+// Hash is implemented by the objs whose hashes may be known, it's used to compare and
+// verify files. it should return the known hashes only, rather than compute them
+type Hash interface {
+	GetHash() utils.HashInfo
+}
+
 type SetID interface {
 	SetID(id string)
 }
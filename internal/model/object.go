@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
 
 type Object struct {
 	ID       string
@@ -8,6 +12,7 @@ type Object struct {
 	Size     int64
 	Modified time.Time
 	IsFolder bool
+	Hash     utils.HashInfo
 }
 
 func (f Object) GetName() string {
@@ -30,6 +35,10 @@ func (f Object) GetID() string {
 	return f.ID
 }
 
+func (f Object) GetHash() utils.HashInfo {
+	return f.Hash
+}
+
 func (f *Object) SetID(id string) {
 	f.ID = id
 }
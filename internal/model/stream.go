@@ -2,6 +2,8 @@ package model
 
 import (
 	"io"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type FileStream struct {
@@ -19,6 +21,14 @@ func (f FileStream) NeedStore() bool {
 	return f.WebPutAsTask
 }
 
+// GetHash return the known hashes of the file
+func (f FileStream) GetHash() utils.HashInfo {
+	if h, ok := f.Obj.(Hash); ok {
+		return h.GetHash()
+	}
+	return nil
+}
+
 func (f *FileStream) GetReadCloser() io.ReadCloser {
 	return f.ReadCloser
 }
@@ -26,3 +36,20 @@ func (f *FileStream) GetReadCloser() io.ReadCloser {
 func (f *FileStream) SetReadCloser(rc io.ReadCloser) {
 	f.ReadCloser = rc
 }
+
+// MultipartUpload is an upload in progress by parts, the Name, Size and PartSize are set
+// by the caller, and the ID and Key are set by the driver to identify the upload
+type MultipartUpload struct {
+	ID       string
+	Key      string
+	Name     string
+	Size     int64
+	PartSize int64
+}
+
+// UploadedPart is the result of uploading a part, which is needed to complete the upload
+type UploadedPart struct {
+	Number int
+	ETag   string
+	Size   int64
+}
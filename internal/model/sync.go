@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// modes of sync jobs
+const (
+	SyncCopyNew = "copy_new" // copy the new and changed files from src to dst
+	SyncMirror  = "mirror"   // make dst the same as src, the extra files of dst are deleted
+	SyncTwoWay  = "two_way"  // copy the new and changed files of each side to the other side
+)
+
+// SyncJob sync the files from SrcPath to DstPath on the Cron schedule, the paths are virtual paths
+type SyncJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" binding:"required"`
+	SrcPath  string `json:"src_path" binding:"required"`
+	DstPath  string `json:"dst_path" binding:"required"`
+	Cron     string `json:"cron" binding:"required"`
+	Mode     string `json:"mode"`
+	Disabled bool   `json:"disabled"`
+	// LastTaskID is the copy task of the last run
+	LastTaskID uint64    `json:"last_task_id"`
+	LastRun    time.Time `json:"last_run"`
+}
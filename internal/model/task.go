@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// TaskItem is a task persisted in database, Type is the task manager it belongs to
+type TaskItem struct {
+	Type       string    `json:"type" gorm:"primaryKey"`
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`
+	Args       string    `json:"args" gorm:"type:text"`
+	State      string    `json:"state"`
+	Status     string    `json:"status"`
+	Progress   int       `json:"progress"`
+	Error      string    `json:"error" gorm:"type:text"`
+	Checkpoint string    `json:"checkpoint" gorm:"type:text"`
+	ParentID   string    `json:"parent_id"`
+	Group      bool      `json:"group"`
+	Size       int64     `json:"size"`
+	Creator    string    `json:"creator"`
+	Priority   int       `json:"priority"`
+	Attempts   string    `json:"attempts" gorm:"type:text"` // json of the failed attempts
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
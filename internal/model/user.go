@@ -29,6 +29,9 @@ type User struct {
 	//  8: webdav read
 	//  9: webdav write
 	Permission int32 `json:"permission"`
+	// the bandwidth of the user in KiB/s, 0 is unlimited
+	DownLimit int64 `json:"down_limit"`
+	UpLimit   int64 `json:"up_limit"`
 }
 
 func (u User) IsGuest() bool {
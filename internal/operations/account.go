@@ -51,11 +51,96 @@ func CreateAccount(ctx context.Context, account model.Account) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed init account but account is already created")
 	}
+	accountDriver.SetStatus(statusOK)
+	MustSaveDriverAccount(accountDriver)
 	log.Debugf("account %+v is created", accountDriver)
 	accountsMap.Store(account.VirtualPath, accountDriver)
 	return nil
 }
 
+// LoadAccount instantiate the driver of an account that already saved in database and init it.
+// the account will be kept in memory even if init failed, the error is recorded in account's status,
+// so that it can be listed and reloaded later. if the account is already loaded, drop it first
+func LoadAccount(ctx context.Context, account model.Account) error {
+	account.VirtualPath = utils.StandardizePath(account.VirtualPath)
+	driverNew, err := GetDriverNew(account.Driver)
+	if err != nil {
+		return errors.WithMessage(err, "failed get driver new")
+	}
+	if oldDriver, ok := accountsMap.Load(account.VirtualPath); ok {
+		if err := oldDriver.Drop(ctx); err != nil {
+			log.Errorf("failed drop account [%s] before load: %+v", account.VirtualPath, err)
+		}
+	}
+	accountDriver := driverNew()
+	err = initAccount(ctx, accountDriver, account)
+	if errors.Is(err, errInitTimeout) {
+		// the driver is still being initialized, so it's discarded
+		// and only the status is saved
+		accountsMap.Delete(account.VirtualPath)
+		account.SetStatus(err.Error())
+		if err := db.UpdateAccount(&account); err != nil {
+			log.Errorf("failed save status of account [%s]: %+v", account.VirtualPath, err)
+		}
+		return errors.WithMessagef(err, "failed init account [%s]", account.VirtualPath)
+	}
+	if err != nil {
+		accountDriver.SetStatus(err.Error())
+		MustSaveDriverAccount(accountDriver)
+		accountsMap.Store(account.VirtualPath, accountDriver)
+		return errors.WithMessagef(err, "failed init account [%s]", account.VirtualPath)
+	}
+	accountDriver.SetStatus(statusOK)
+	MustSaveDriverAccount(accountDriver)
+	accountsMap.Store(account.VirtualPath, accountDriver)
+	log.Debugf("account %+v is loaded", accountDriver)
+	return nil
+}
+
+// statusOK is the status of the account initialized successfully, the accounts
+// in other status can't be used
+const statusOK = "OK"
+
+var errInitTimeout = errors.New("init account timeout")
+
+// initAccount call Init of the driver, return when Init finished or ctx is done,
+// so that a driver which doesn't respect ctx can't block the caller.
+// the driver timed out is dropped after its Init finished, it shouldn't be used
+func initAccount(ctx context.Context, accountDriver driver.Driver, account model.Account) error {
+	errC := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				errC <- errors.Errorf("panic while init account: %+v", e)
+			}
+		}()
+		errC <- accountDriver.Init(ctx, account)
+	}()
+	select {
+	case err := <-errC:
+		return err
+	case <-ctx.Done():
+		go func() {
+			if err := <-errC; err == nil {
+				if err := accountDriver.Drop(context.Background()); err != nil {
+					log.Errorf("failed drop account [%s] timed out: %+v", account.VirtualPath, err)
+				}
+			}
+		}()
+		return errors.WithMessage(errInitTimeout, ctx.Err().Error())
+	}
+}
+
+// ReloadAccountById reload the account from database,
+// used to retry an account which failed to init without touching the others
+func ReloadAccountById(ctx context.Context, id uint) error {
+	account, err := db.GetAccountById(id)
+	if err != nil {
+		return errors.WithMessage(err, "failed get account")
+	}
+	return LoadAccount(ctx, *account)
+}
+
 // UpdateAccount update account
 // get old account first
 // drop the account then reinitialize
@@ -87,8 +172,14 @@ func UpdateAccount(ctx context.Context, account model.Account) error {
 	}
 	err = accountDriver.Init(ctx, account)
 	if err != nil {
+		// keep it in status, so that the driver isn't used
+		accountDriver.SetStatus(err.Error())
+		MustSaveDriverAccount(accountDriver)
+		accountsMap.Store(account.VirtualPath, accountDriver)
 		return errors.WithMessage(err, "failed init account")
 	}
+	accountDriver.SetStatus(statusOK)
+	MustSaveDriverAccount(accountDriver)
 	accountsMap.Store(account.VirtualPath, accountDriver)
 	return nil
 }
@@ -211,6 +302,7 @@ func GetAccountVirtualFilesByPath(prefix string) []model.Obj {
 			Name:     name,
 			Size:     0,
 			Modified: v.GetAccount().Modified,
+			IsFolder: true,
 		})
 		set[name] = nil
 	}
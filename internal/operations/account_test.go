@@ -2,8 +2,14 @@ package operations_test
 
 import (
 	"context"
-	"github.com/alist-org/alist/v3/internal/db"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
 
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/operations"
@@ -68,6 +74,106 @@ func TestGetBalancedAccount(t *testing.T) {
 	}
 }
 
+func TestLoadAccount(t *testing.T) {
+	account := model.Account{Driver: "Local", VirtualPath: "/load", Addition: `{"root_folder":"./not_exists"}`}
+	err := db.CreateAccount(&account)
+	if err != nil {
+		t.Fatalf("failed to create account in database: %+v", err)
+	}
+	err = operations.LoadAccount(context.Background(), account)
+	if err == nil {
+		t.Errorf("expected error while load account with a not exists root folder")
+	}
+	accountDriver, err := operations.GetAccountByVirtualPath("/load")
+	if err != nil {
+		t.Fatalf("expected account is kept in memory even if init failed: %+v", err)
+	}
+	if accountDriver.GetAccount().Status == "OK" {
+		t.Errorf("expected error status, got: %s", accountDriver.GetAccount().Status)
+	}
+	saved, err := db.GetAccountById(account.ID)
+	if err != nil {
+		t.Fatalf("failed to get account: %+v", err)
+	}
+	if saved.Status != accountDriver.GetAccount().Status {
+		t.Errorf("expected status saved in database: %s, got: %s", accountDriver.GetAccount().Status, saved.Status)
+	}
+	if _, _, err := operations.GetAccountAndActualPath("/load/a.txt"); err == nil {
+		t.Errorf("expected error while use the account failed to init")
+	}
+}
+
+func TestUpdateAccountRenameFailed(t *testing.T) {
+	account := model.Account{Driver: "Local", VirtualPath: "/update", Addition: fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(t.TempDir()))}
+	if err := db.CreateAccount(&account); err != nil {
+		t.Fatalf("failed to create account in database: %+v", err)
+	}
+	if err := operations.LoadAccount(context.Background(), account); err != nil {
+		t.Fatalf("failed to load account: %+v", err)
+	}
+	account.VirtualPath = "/update_renamed"
+	account.Addition = `{"root_folder":"./not_exists"}`
+	if err := operations.UpdateAccount(context.Background(), account); err == nil {
+		t.Errorf("expected error while update account with a not exists root folder")
+	}
+	if _, err := operations.GetAccountByVirtualPath("/update"); err == nil {
+		t.Errorf("expected the old virtual path removed")
+	}
+	accountDriver, err := operations.GetAccountByVirtualPath("/update_renamed")
+	if err != nil {
+		t.Fatalf("expected account is kept under the new virtual path even if init failed: %+v", err)
+	}
+	if accountDriver.GetAccount().Status == "OK" {
+		t.Errorf("expected error status, got: %s", accountDriver.GetAccount().Status)
+	}
+}
+
+// slowDriver is a Local driver whose Init ignores ctx and blocks until init is closed
+type slowDriver struct {
+	local.Driver
+	init    chan struct{}
+	dropped chan struct{}
+}
+
+func (d *slowDriver) Init(ctx context.Context, account model.Account) error {
+	<-d.init
+	return d.Driver.Init(ctx, account)
+}
+
+func (d *slowDriver) Drop(ctx context.Context) error {
+	close(d.dropped)
+	return nil
+}
+
+func TestLoadAccountTimeout(t *testing.T) {
+	slow := &slowDriver{init: make(chan struct{}), dropped: make(chan struct{})}
+	operations.RegisterDriver(driver.Config{Name: "Slow"}, func() driver.Driver {
+		return slow
+	})
+	account := model.Account{Driver: "Slow", VirtualPath: "/slow", Addition: `{"root_folder":"."}`}
+	if err := db.CreateAccount(&account); err != nil {
+		t.Fatalf("failed to create account in database: %+v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := operations.LoadAccount(ctx, account); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if _, err := operations.GetAccountByVirtualPath("/slow"); err == nil {
+		t.Errorf("the driver timed out should be discarded")
+	}
+	saved, err := db.GetAccountById(account.ID)
+	if err != nil || saved.Status == "OK" || saved.Status == "" {
+		t.Errorf("expected timeout status saved in database: %+v %+v", saved, err)
+	}
+	close(slow.init)
+	select {
+	case <-slow.dropped:
+	case <-time.After(time.Second):
+		t.Errorf("the driver timed out should be dropped after its init finished")
+	}
+}
+
 func setupAccounts(t *testing.T) {
 	var accounts = []model.Account{
 		{Driver: "Local", VirtualPath: "/a/b", Index: 0, Addition: `{"root_folder":"."}`},
@@ -2,6 +2,7 @@ package operations
 
 import (
 	"context"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	log "github.com/sirupsen/logrus"
@@ -13,7 +14,9 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
@@ -155,7 +158,7 @@ func MakeDir(ctx context.Context, account driver.Driver, path string) error {
 	f, err := Get(ctx, account, path)
 	if err != nil {
 		if errs.IsObjectNotFound(err) {
-			parentPath, dirName := stdpath.Split(path)
+			parentPath, dirName := stdpath.Dir(path), stdpath.Base(path)
 			err = MakeDir(ctx, account, parentPath)
 			if err != nil {
 				return errors.WithMessagef(err, "failed to make parent dir [%s]", parentPath)
@@ -222,9 +225,19 @@ func Remove(ctx context.Context, account driver.Driver, path string) error {
 	return account.Remove(ctx, obj)
 }
 
-func Put(ctx context.Context, account driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+func Put(ctx context.Context, account driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) (err error) {
+	rc := file.GetReadCloser()
+	// the temp file which the stream is cached to, it's always removed
+	var cached *os.File
 	defer func() {
-		if f, ok := file.GetReadCloser().(*os.File); ok {
+		if cached != nil {
+			_ = os.Remove(cached.Name())
+		}
+		// keep the file of the paused or retryable task, it will be put again
+		if task.WillRunAgain(ctx, err) {
+			return
+		}
+		if f, ok := rc.(*os.File); ok {
 			err := os.RemoveAll(f.Name())
 			if err != nil {
 				log.Errorf("failed to remove file [%s]", f.Name())
@@ -236,20 +249,45 @@ func Put(ctx context.Context, account driver.Driver, dstDirPath string, file mod
 			log.Errorf("failed to close file streamer, %v", err)
 		}
 	}()
-	err := MakeDir(ctx, account, dstDirPath)
-	if err != nil {
-		return errors.WithMessagef(err, "failed to make dir [%s]", dstDirPath)
-	}
-	parentDir, err := Get(ctx, account, dstDirPath)
-	// this should not happen
+	parentDir, err := putDir(ctx, account, dstDirPath)
 	if err != nil {
-		return errors.WithMessagef(err, "failed to get dir [%s]", dstDirPath)
+		return err
 	}
 	// if up is nil, set a default to prevent panic
 	if up == nil {
 		up = func(p int) {}
 	}
-	err = account.Put(ctx, parentDir, file, up)
+	if rapid, ok := account.(driver.RapidUploader); ok {
+		var hashes utils.HashInfo
+		hashes, cached, err = streamHashes(file, rapid.RapidHashTypes())
+		if cached != nil {
+			// the stream is read out and the temp file is read from then on
+			_ = rc.Close()
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed get hashes to rapid put")
+		}
+		if hashes != nil {
+			ok, err := rapidPut(ctx, rapid, parentDir, file, hashes)
+			if err != nil {
+				return err
+			}
+			if ok {
+				up(100)
+				filesCache.Del(stdpath.Join(account.GetAccount().VirtualPath, dstDirPath))
+				return nil
+			}
+		}
+	}
+	if limiters := bandwidth.UpLimiters(bandwidth.UserOf(ctx), account.GetAccount()); len(limiters) > 0 {
+		r := file.GetReadCloser()
+		file.SetReadCloser(utils.ReadCloser{Reader: ratelimit.NewReader(ctx, r, limiters...), Closer: r})
+	}
+	if uploader, ok := account.(driver.MultipartUploader); ok && file.GetSize() > partSize() {
+		err = putMultipart(ctx, uploader, parentDir, file, up, partSize(), uploadThreads())
+	} else {
+		err = account.Put(ctx, parentDir, file, up)
+	}
 	log.Debugf("put file [%s] done", file.GetName())
 	if err == nil {
 		// clear cache
@@ -258,3 +296,17 @@ func Put(ctx context.Context, account driver.Driver, dstDirPath string, file mod
 	}
 	return err
 }
+
+// putDir make the dir to put files and get it
+func putDir(ctx context.Context, account driver.Driver, dstDirPath string) (model.Obj, error) {
+	err := MakeDir(ctx, account, dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to make dir [%s]", dstDirPath)
+	}
+	parentDir, err := Get(ctx, account, dstDirPath)
+	// this should not happen
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get dir [%s]", dstDirPath)
+	}
+	return parentDir, nil
+}
@@ -0,0 +1,164 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// rapidDriver is a Local driver which records the rapid put and falls back to put normally
+type rapidDriver struct {
+	local.Driver
+	hashes utils.HashInfo
+}
+
+func (d *rapidDriver) RapidPut(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, hashes utils.HashInfo) (bool, error) {
+	d.hashes = hashes
+	return false, nil
+}
+
+func TestPutRapid(t *testing.T) {
+	root := t.TempDir()
+	d := &rapidDriver{}
+	err := d.Init(context.Background(), model.Account{Addition: `{"root_folder":"` + filepath.ToSlash(root) + `"}`})
+	if err != nil {
+		t.Fatalf("failed to init: %+v", err)
+	}
+	data := []byte("rapid content")
+	tempFile := writeFile(t, filepath.Join(t.TempDir(), "temp"), data)
+	md5, _ := utils.HashFile(tempFile, utils.MD5)
+
+	// the rapid put is tried with the hashes of the temp file, then it's put normally
+	f, err := os.Open(tempFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &model.FileStream{
+		Obj:        model.Object{Name: "a.txt", Size: int64(len(data)), Modified: time.Now()},
+		ReadCloser: f,
+	}
+	if err := operations.Put(context.Background(), d, root, stream, nil); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if d.hashes[utils.MD5] != md5[utils.MD5] {
+		t.Errorf("expected rapid put tried with %v, got %v", md5, d.hashes)
+	}
+	if got, err := ioutil.ReadFile(filepath.Join(root, "a.txt")); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("unexpected put file: %s %v", got, err)
+	}
+	if utils.Exists(tempFile) {
+		t.Errorf("expected the temp file removed after put")
+	}
+	// the other streams are cached to a temp file to compute the hashes
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	d.hashes = nil
+	stream = &model.FileStream{
+		Obj:        model.Object{Name: "d.txt", Size: int64(len(data)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+	}
+	if err := operations.Put(context.Background(), d, root, stream, nil); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if d.hashes[utils.MD5] != md5[utils.MD5] {
+		t.Errorf("expected rapid put tried with %v, got %v", md5, d.hashes)
+	}
+	if got, err := ioutil.ReadFile(filepath.Join(root, "d.txt")); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("unexpected put file: %s %v", got, err)
+	}
+	if files, _ := ioutil.ReadDir(conf.Conf.TempDir); len(files) != 0 {
+		t.Errorf("expected the cached stream removed after put")
+	}
+
+	// Local copies the file put before with the same hashes
+	stream = &model.FileStream{Obj: model.Object{Name: "b.txt", Size: int64(len(data)), Hash: md5}}
+	ok, err := operations.RapidPut(context.Background(), &d.Driver, root, stream)
+	if err != nil || !ok {
+		t.Fatalf("expected rapid put, got %v %+v", ok, err)
+	}
+	if got, err := ioutil.ReadFile(filepath.Join(root, "b.txt")); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("unexpected rapid put file: %s %v", got, err)
+	}
+	stream.Obj = model.Object{Name: "c.txt", Size: int64(len(data)), Hash: utils.HashInfo{utils.MD5: "unknown"}}
+	if ok, err := operations.RapidPut(context.Background(), &d.Driver, root, stream); ok || err != nil {
+		t.Errorf("expected no file with the hashes, got %v %+v", ok, err)
+	}
+}
+
+func TestLocalHash(t *testing.T) {
+	root := t.TempDir()
+	d := &local.Driver{}
+	err := d.Init(context.Background(), model.Account{Addition: `{"root_folder":"` + filepath.ToSlash(root) + `"}`})
+	if err != nil {
+		t.Fatalf("failed to init: %+v", err)
+	}
+	path := writeFile(t, filepath.Join(root, "a.txt"), []byte("not put by the driver"))
+	expected, _ := utils.HashFile(path, utils.StandardHashTypes...)
+
+	// the hashes are unknown to the list until they are asked for
+	objs, err := d.List(context.Background(), &model.Object{ID: root})
+	if err != nil || len(objs) != 1 || operations.HashOf(objs[0]) != nil {
+		t.Fatalf("expected no hashes listed, got %+v", err)
+	}
+	obj, err := d.Get(context.Background(), path)
+	if err != nil {
+		t.Fatalf("failed to get: %+v", err)
+	}
+	if equal, compared := operations.HashOf(obj).Equal(expected); !equal || !compared {
+		t.Errorf("expected hashes %v, got %v", expected, operations.HashOf(obj))
+	}
+	objs, _ = d.List(context.Background(), &model.Object{ID: root})
+	if equal, compared := operations.HashOf(objs[0]).Equal(expected); !equal || !compared {
+		t.Errorf("expected the cached hashes listed, got %v", operations.HashOf(objs[0]))
+	}
+
+	// the modified file is computed again
+	writeFile(t, path, []byte("modified"))
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	expected, _ = utils.HashFile(path, utils.StandardHashTypes...)
+	obj, _ = d.Get(context.Background(), path)
+	if equal, compared := operations.HashOf(obj).Equal(expected); !equal || !compared {
+		t.Errorf("expected hashes %v, got %v", expected, operations.HashOf(obj))
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) string {
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMakeDir(t *testing.T) {
+	root := t.TempDir()
+	err := operations.CreateAccount(context.Background(), model.Account{
+		Driver:      "Local",
+		VirtualPath: "/mkdir",
+		Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create account: %+v", err)
+	}
+	// the parent dirs which don't exist are made too
+	account, actualPath, err := operations.GetAccountAndActualPath("/mkdir/a/b/c")
+	if err != nil {
+		t.Fatalf("failed to get account: %+v", err)
+	}
+	if err := operations.MakeDir(context.Background(), account, actualPath); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	if info, err := os.Stat(filepath.Join(root, "a", "b", "c")); err != nil || !info.IsDir() {
+		t.Errorf("expected the dir made, got %+v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// HashOf return the known hashes of the obj
+func HashOf(obj model.Obj) utils.HashInfo {
+	if h, ok := obj.(model.Hash); ok {
+		return h.GetHash()
+	}
+	return nil
+}
+
+// FileHashes compute the hashes of the local file of the types and the standard ones known,
+// the known ones are compared to find the file corrupted while downloading
+func FileHashes(known utils.HashInfo, path string, types ...utils.HashType) (utils.HashInfo, error) {
+	types = append([]utils.HashType(nil), types...)
+	for _, t := range utils.StandardHashTypes {
+		if known[t] != "" && !utils.SliceContains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return known, nil
+	}
+	hashes, err := utils.HashFile(path, types...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed compute hashes")
+	}
+	if equal, _ := hashes.Equal(known); !equal {
+		return nil, errors.Wrapf(errs.VerifyFailed, "the hashes of [%s] are %s, but expected %s", path, hashes, known)
+	}
+	for t, h := range known {
+		hashes[t] = h
+	}
+	return hashes, nil
+}
+
+// DstHashTypes return the hash types to compute for verifying the file put to the account,
+// none if the account can't report the hashes
+func DstHashTypes(account driver.Driver) []utils.HashType {
+	if account.Config().Hash {
+		return utils.StandardHashTypes
+	}
+	return nil
+}
+
+// Verify check the put file by its size and the hashes of the types the put file has,
+// so that the truncated or corrupted file is found
+func Verify(ctx context.Context, account driver.Driver, path string, size int64, hashes utils.HashInfo) error {
+	obj, err := Get(ctx, account, path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get put file [%s]", path)
+	}
+	if obj.GetSize() != size {
+		return errors.Wrapf(errs.VerifyFailed, "the size of [%s] is %d, but expected %d", path, obj.GetSize(), size)
+	}
+	equal, compared := hashes.Equal(HashOf(obj))
+	if !equal {
+		return errors.Wrapf(errs.VerifyFailed, "the hashes of [%s] are %s, but expected %s", path, HashOf(obj), hashes)
+	}
+	if !compared {
+		log.Debugf("no common hash type to verify [%s], only the size is verified", path)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func TestFileHashes(t *testing.T) {
+	path := writeTemp(t, []byte("0123456789"))
+	expected, _ := utils.HashFile(path, utils.StandardHashTypes...)
+
+	// nothing to compute
+	hashes, err := FileHashes(nil, path)
+	if err != nil || len(hashes) != 0 {
+		t.Errorf("expected no hashes, got %v %+v", hashes, err)
+	}
+	// the known one is compared even if no type is required
+	hashes, err = FileHashes(utils.HashInfo{utils.MD5: expected[utils.MD5], "crc32": "x"}, path)
+	if err != nil || hashes[utils.MD5] != expected[utils.MD5] || hashes["crc32"] != "x" || hashes[utils.SHA1] != "" {
+		t.Errorf("unexpected hashes: %v %+v", hashes, err)
+	}
+	hashes, err = FileHashes(utils.HashInfo{utils.MD5: expected[utils.MD5]}, path, utils.StandardHashTypes...)
+	if err != nil || hashes[utils.SHA256] != expected[utils.SHA256] {
+		t.Errorf("unexpected hashes: %v %+v", hashes, err)
+	}
+	// the file is corrupted
+	_, err = FileHashes(utils.HashInfo{utils.SHA1: expected[utils.MD5]}, path)
+	if !errors.Is(err, errs.VerifyFailed) {
+		t.Errorf("expected verify failed, got %+v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// partSize is the suggested part size of MultipartUploader, the files not larger than it are put directly
+func partSize() int64 {
+	size := setting.GetIntSetting(conf.UploadPartSize, 16)
+	if size <= 0 {
+		size = 16
+	}
+	return int64(size) * 1024 * 1024
+}
+
+func uploadThreads() int {
+	threads := setting.GetIntSetting(conf.UploadThreads, 3)
+	if threads <= 0 {
+		threads = 1
+	}
+	return threads
+}
+
+// putMultipart upload the file by parts, the parts are read in order and uploaded concurrently,
+// so at most threads parts are buffered in memory. the upload is aborted if any part failed
+// or the ctx canceled
+func putMultipart(ctx context.Context, uploader driver.MultipartUploader, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress, size int64, threads int) error {
+	upload := &model.MultipartUpload{
+		Name:     file.GetName(),
+		Size:     file.GetSize(),
+		PartSize: size,
+	}
+	if err := uploader.InitUpload(ctx, dstDir, file, upload); err != nil {
+		return errors.WithMessage(err, "failed init multipart upload")
+	}
+	if upload.PartSize <= 0 {
+		return errors.Errorf("invalid part size %d", upload.PartSize)
+	}
+	count := int((upload.Size + upload.PartSize - 1) / upload.PartSize)
+	parts := make([]model.UploadedPart, count)
+
+	partCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		err      error
+		uploaded int64
+	)
+	fail := func(e error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			err = e
+		}
+		cancel()
+	}
+	sem := make(chan struct{}, threads)
+	for i := 0; i < count; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-partCtx.Done():
+		}
+		if partCtx.Err() != nil {
+			break
+		}
+		length := upload.PartSize
+		if rest := upload.Size - int64(i)*upload.PartSize; rest < length {
+			length = rest
+		}
+		buf := make([]byte, length)
+		if _, e := io.ReadFull(file, buf); e != nil {
+			<-sem
+			fail(errors.Wrapf(e, "failed read part %d", i+1))
+			break
+		}
+		wg.Add(1)
+		go func(number int, buf []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			part, e := uploader.UploadPart(partCtx, upload, number, bytes.NewReader(buf), int64(len(buf)))
+			if e != nil {
+				fail(errors.WithMessagef(e, "failed upload part %d", number))
+				return
+			}
+			if part.Number == 0 {
+				part.Number = number
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			parts[number-1] = *part
+			uploaded += int64(len(buf))
+			up(int(uploaded * 100 / upload.Size))
+		}(i+1, buf)
+	}
+	wg.Wait()
+	if err == nil && ctx.Err() != nil {
+		err = errors.WithStack(ctx.Err())
+	}
+	if err == nil {
+		err = uploader.CompleteUpload(ctx, upload, parts)
+		if err == nil {
+			return nil
+		}
+		err = errors.WithMessage(err, "failed complete multipart upload")
+	}
+	// the ctx may be canceled, so abort with a new one
+	if e := uploader.AbortUpload(context.Background(), upload); e != nil {
+		log.Errorf("failed abort multipart upload of %s: %+v", upload.Name, e)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// memUploader keep the uploaded parts in memory, the part numbered failPart fails
+type memUploader struct {
+	mu       sync.Mutex
+	parts    map[int][]byte
+	running  int
+	maxRun   int
+	failPart int
+	result   []byte
+	aborted  bool
+}
+
+func (m *memUploader) InitUpload(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, upload *model.MultipartUpload) error {
+	m.parts = make(map[int][]byte)
+	upload.ID = "upload"
+	return nil
+}
+
+func (m *memUploader) UploadPart(ctx context.Context, upload *model.MultipartUpload, number int, part io.ReadSeeker, size int64) (*model.UploadedPart, error) {
+	m.mu.Lock()
+	m.running++
+	if m.running > m.maxRun {
+		m.maxRun = m.running
+	}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.running--
+		m.mu.Unlock()
+	}()
+	// let the parts overlap
+	time.Sleep(10 * time.Millisecond)
+	if number == m.failPart {
+		return nil, errors.New("part failed")
+	}
+	data, err := ioutil.ReadAll(part)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[number] = data
+	return &model.UploadedPart{Number: number, Size: size}, nil
+}
+
+func (m *memUploader) CompleteUpload(ctx context.Context, upload *model.MultipartUpload, parts []model.UploadedPart) error {
+	for i, part := range parts {
+		if part.Number != i+1 {
+			return errors.Errorf("unexpected part %d at %d", part.Number, i)
+		}
+		m.result = append(m.result, m.parts[part.Number]...)
+	}
+	return nil
+}
+
+func (m *memUploader) AbortUpload(ctx context.Context, upload *model.MultipartUpload) error {
+	m.aborted = true
+	return nil
+}
+
+func newStream(data []byte) *model.FileStream {
+	return &model.FileStream{
+		Obj:        model.Object{Name: "a.bin", Size: int64(len(data)), Modified: time.Now()},
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+func TestPutMultipart(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 105)
+	m := &memUploader{}
+	progress := 0
+	err := putMultipart(context.Background(), m, &model.Object{}, newStream(data), func(p int) {
+		progress = p
+	}, 100, 3)
+	if err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	if !bytes.Equal(m.result, data) || len(m.parts) != 11 {
+		t.Errorf("unexpected result of %d parts: %d bytes", len(m.parts), len(m.result))
+	}
+	if progress != 100 {
+		t.Errorf("expected progress 100, but got %d", progress)
+	}
+	if m.maxRun < 2 || m.maxRun > 3 {
+		t.Errorf("expected 2 or 3 parts uploaded concurrently, but got %d", m.maxRun)
+	}
+
+	m = &memUploader{failPart: 4}
+	if err := putMultipart(context.Background(), m, &model.Object{}, newStream(data), func(int) {}, 100, 3); err == nil {
+		t.Errorf("expected error of the failed part")
+	}
+	if !m.aborted || m.result != nil {
+		t.Errorf("expected the upload aborted")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m = &memUploader{}
+	err = putMultipart(ctx, m, &model.Object{}, newStream(data), func(p int) {
+		if p > 20 {
+			cancel()
+		}
+	}, 100, 3)
+	if !errors.Is(err, context.Canceled) || !m.aborted {
+		t.Errorf("expected the canceled upload aborted, but got %v", err)
+	}
+}
@@ -29,7 +29,11 @@ func GetAccountAndActualPath(rawPath string) (driver.Driver, string, error) {
 	}
 	account := GetBalancedAccount(rawPath)
 	if account == nil {
-		return nil, "", errors.Errorf("can't find account with rawPath: %s", rawPath)
+		return nil, "", errors.WithMessagef(errs.AccountNotFound, "can't find account with rawPath: %s", rawPath)
+	}
+	// the driver failed to init may be not ready, e.g. its client is nil
+	if status := account.GetAccount().Status; status != statusOK {
+		return nil, "", errors.Errorf("account %s is not available: %s", account.GetAccount().VirtualPath, status)
 	}
 	log.Debugln("use account: ", account.GetAccount().VirtualPath)
 	virtualPath := utils.GetActualVirtualPath(account.GetAccount().VirtualPath)
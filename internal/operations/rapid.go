@@ -0,0 +1,103 @@
+package operations
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// streamHashes return the hashes of the types of the stream, the unknown standard ones are
+// computed from the content, which is cached to a temp file if it's not a file, then the
+// stream reads from the returned temp file. the hashes are nil if any type can't be computed
+func streamHashes(file model.FileStreamer, types []utils.HashType) (hashes utils.HashInfo, cached *os.File, err error) {
+	hashes = make(utils.HashInfo)
+	known := HashOf(file)
+	var missing []utils.HashType
+	for _, t := range types {
+		if known[t] != "" {
+			hashes[t] = known[t]
+			continue
+		}
+		if t.New() == nil {
+			return nil, nil, nil
+		}
+		missing = append(missing, t)
+	}
+	if len(missing) == 0 {
+		return hashes, nil, nil
+	}
+	f, ok := file.GetReadCloser().(*os.File)
+	if !ok {
+		f, err = utils.CreateTempFile(file.GetReadCloser())
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed cache the stream to temp file")
+		}
+		file.SetReadCloser(f)
+		cached = f
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, cached, errors.WithStack(err)
+	}
+	hasher := utils.NewMultiHasher(missing...)
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, cached, errors.Wrap(err, "failed read the stream")
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, cached, errors.WithStack(err)
+	}
+	for t, h := range hasher.Sum() {
+		hashes[t] = h
+	}
+	return hashes, cached, nil
+}
+
+// rapidPut try to put the file by the hashes, the failure of the driver is ignored
+// so that the file is put normally, unless the ctx is canceled
+func rapidPut(ctx context.Context, rapid driver.RapidUploader, dstDir model.Obj, file model.FileStreamer, hashes utils.HashInfo) (bool, error) {
+	ok, err := rapid.RapidPut(ctx, dstDir, file, hashes)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, errors.WithStack(ctx.Err())
+		}
+		log.Warnf("failed rapid put [%s], put it normally: %+v", file.GetName(), err)
+		return false, nil
+	}
+	if ok {
+		log.Debugf("rapid put file [%s] done", file.GetName())
+	}
+	return ok, nil
+}
+
+// RapidPut try to put the file by its known hashes without reading it, ok is false if the
+// driver doesn't support it or the hashes are not known, then the file should be put normally.
+// it's used to skip downloading the file, which is needed by Put
+func RapidPut(ctx context.Context, account driver.Driver, dstDirPath string, file model.FileStreamer) (bool, error) {
+	rapid, ok := account.(driver.RapidUploader)
+	if !ok {
+		return false, nil
+	}
+	known, hashes := HashOf(file), make(utils.HashInfo)
+	for _, t := range rapid.RapidHashTypes() {
+		if known[t] == "" {
+			return false, nil
+		}
+		hashes[t] = known[t]
+	}
+	parentDir, err := putDir(ctx, account, dstDirPath)
+	if err != nil {
+		return false, err
+	}
+	ok, err = rapidPut(ctx, rapid, parentDir, file, hashes)
+	if ok {
+		filesCache.Del(stdpath.Join(account.GetAccount().VirtualPath, dstDirPath))
+	}
+	return ok, err
+}
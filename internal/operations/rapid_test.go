@@ -0,0 +1,102 @@
+package operations
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func TestStreamHashes(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	data := []byte("0123456789")
+	path := writeTemp(t, data)
+	sha1, _ := utils.HashFile(path, utils.SHA1)
+
+	// the local file is read from the start again after computing, it's not cached
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	file := newStream(data)
+	file.SetReadCloser(f)
+	hashes, cached, err := streamHashes(file, []utils.HashType{utils.SHA1})
+	if err != nil || cached != nil || hashes[utils.SHA1] != sha1[utils.SHA1] {
+		t.Errorf("unexpected hashes: %v %v", hashes, err)
+	}
+	if got, _ := ioutil.ReadAll(file); string(got) != string(data) {
+		t.Errorf("expected the stream read from the start, got %s", got)
+	}
+
+	// the stream is cached to a temp file, which is read from then on
+	file = newStream(data)
+	hashes, cached, err = streamHashes(file, []utils.HashType{utils.SHA1})
+	if err != nil || cached == nil {
+		t.Fatalf("expected the stream cached, got %v", err)
+	}
+	defer os.Remove(cached.Name())
+	if hashes[utils.SHA1] != sha1[utils.SHA1] {
+		t.Errorf("unexpected hashes: %v", hashes)
+	}
+	if got, _ := ioutil.ReadAll(file); string(got) != string(data) {
+		t.Errorf("expected the stream read from the start, got %s", got)
+	}
+
+	// the known hashes are used without reading
+	file = newStream(data)
+	file.Obj = model.Object{Name: "a.bin", Size: 10, Hash: utils.HashInfo{"etag": "x"}}
+	hashes, cached, err = streamHashes(file, []utils.HashType{"etag"})
+	if err != nil || cached != nil || hashes["etag"] != "x" {
+		t.Errorf("expected the known hash, got %v %v", hashes, err)
+	}
+	// the unknown hash of a non standard type can't be computed
+	hashes, _, _ = streamHashes(newStream(data), []utils.HashType{"etag"})
+	if hashes != nil {
+		t.Errorf("expected no hashes, got %v", hashes)
+	}
+}
+
+type failedRapid struct {
+	err error
+}
+
+func (f failedRapid) RapidHashTypes() []utils.HashType {
+	return []utils.HashType{utils.SHA1}
+}
+
+func (f failedRapid) RapidPut(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, hashes utils.HashInfo) (bool, error) {
+	return false, f.err
+}
+
+func TestRapidPutFallback(t *testing.T) {
+	hashes := utils.HashInfo{utils.SHA1: "x"}
+	ok, err := rapidPut(context.Background(), failedRapid{errors.New("failed")}, &model.Object{}, newStream(nil), hashes)
+	if ok || err != nil {
+		t.Errorf("expected falling back to put normally, got %v %v", ok, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rapidPut(ctx, failedRapid{ctx.Err()}, &model.Object{}, newStream(nil), hashes)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	f, err := ioutil.TempFile(t.TempDir(), "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
@@ -0,0 +1,146 @@
+// Package syncjob manage the sync jobs, which sync the files between two virtual paths on schedule
+package syncjob
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the cancel funcs of the scheduled jobs
+var schedules generic_sync.MapOf[uint, context.CancelFunc]
+
+// Load schedule the enabled jobs in database
+func Load() error {
+	jobs, err := db.GetEnabledSyncJobs()
+	if err != nil {
+		return errors.WithMessage(err, "failed get enabled sync jobs")
+	}
+	for _, job := range jobs {
+		if err := schedule(job); err != nil {
+			log.Errorf("failed schedule sync job [%s]: %+v", job.Name, err)
+		}
+	}
+	return nil
+}
+
+func Create(job *model.SyncJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	if err := db.CreateSyncJob(job); err != nil {
+		return errors.WithMessage(err, "failed create sync job in database")
+	}
+	return schedule(*job)
+}
+
+// Update the job and reschedule it, the last run of it is kept
+func Update(job *model.SyncJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	old, err := db.GetSyncJobById(job.ID)
+	if err != nil {
+		return err
+	}
+	job.LastTaskID = old.LastTaskID
+	job.LastRun = old.LastRun
+	if err := db.UpdateSyncJob(job); err != nil {
+		return errors.WithMessage(err, "failed update sync job in database")
+	}
+	return schedule(*job)
+}
+
+func Delete(id uint) error {
+	unschedule(id)
+	return db.DeleteSyncJobById(id)
+}
+
+// Run the job now, it returns the id of the sync task in fs.CopyTaskManager
+func Run(ctx context.Context, id uint) (uint64, error) {
+	job, err := db.GetSyncJobById(id)
+	if err != nil {
+		return 0, err
+	}
+	// the sync task compares the files, so it shouldn't run while the last one is copying
+	if t, ok := fs.CopyTaskManager.Get(job.LastTaskID); ok && job.LastTaskID != 0 &&
+		!utils.SliceContains([]string{task.SUCCEEDED, task.CANCELED, task.ERRORED}, t.GetState()) {
+		return 0, errors.Errorf("the last run of sync job [%s] is not finished", job.Name)
+	}
+	tid, err := fs.Sync(ctx, job.SrcPath, job.DstPath, job.Mode)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.SetSyncJobLastRun(job.ID, tid, time.Now()); err != nil {
+		log.Errorf("failed save last run of sync job [%s]: %+v", job.Name, err)
+	}
+	return tid, nil
+}
+
+func validate(job *model.SyncJob) error {
+	if _, err := cron.Parse(job.Cron); err != nil {
+		return errors.WithMessage(err, "invalid cron")
+	}
+	if job.Mode == "" {
+		job.Mode = model.SyncCopyNew
+	}
+	if !utils.SliceContains([]string{model.SyncCopyNew, model.SyncMirror, model.SyncTwoWay}, job.Mode) {
+		return errors.Errorf("unknown sync mode: %s", job.Mode)
+	}
+	job.SrcPath = utils.StandardizePath(job.SrcPath)
+	job.DstPath = utils.StandardizePath(job.DstPath)
+	if utils.IsSubPath(job.SrcPath, job.DstPath) || utils.IsSubPath(job.DstPath, job.SrcPath) {
+		return errors.New("src and dst can't be the same or contain each other")
+	}
+	return nil
+}
+
+// schedule run the job on its cron, the job scheduled before is stopped
+func schedule(job model.SyncJob) error {
+	unschedule(job.ID)
+	if job.Disabled {
+		return nil
+	}
+	s, err := cron.Parse(job.Cron)
+	if err != nil {
+		return errors.WithMessage(err, "invalid cron")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	schedules.Store(job.ID, cancel)
+	go func() {
+		for {
+			next := s.Next(time.Now())
+			if next.IsZero() {
+				log.Warnf("sync job [%s] will never run: %s", job.Name, job.Cron)
+				return
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if _, err := Run(ctx, job.ID); err != nil {
+				log.Errorf("failed run sync job [%s]: %+v", job.Name, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func unschedule(id uint) {
+	if cancel, ok := schedules.Load(id); ok {
+		cancel()
+		schedules.Delete(id)
+	}
+}
@@ -0,0 +1,255 @@
+// Package tus store the uploads of the tus resumable upload protocol in the temp dir,
+// the received data of an upload is written to <id> and its info to <id>.info
+package tus
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrLocked         = errors.New("upload is being written by another request")
+)
+
+const infoExt = ".info"
+
+// Upload is the info of an upload, the file is put to Path once all the bytes received
+type Upload struct {
+	ID       string            `json:"id"`
+	Path     string            `json:"path"`
+	Size     int64             `json:"size"`
+	Offset   int64             `json:"offset"`
+	Mimetype string            `json:"mimetype"`
+	AsTask   bool              `json:"as_task"`
+	Metadata map[string]string `json:"metadata"`
+	Creator  string            `json:"creator"`
+	Expires  time.Time         `json:"expires"`
+}
+
+func (u *Upload) Done() bool {
+	return u.Offset >= u.Size
+}
+
+var (
+	mu      sync.Mutex
+	writing = map[string]bool{}
+)
+
+// lock the upload so that only one request writes it, it returns false if it's locked
+func lock(id string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if writing[id] {
+		return false
+	}
+	writing[id] = true
+	return true
+}
+
+func unlock(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(writing, id)
+}
+
+func Dir() string {
+	return filepath.Join(conf.Conf.TempDir, "tus")
+}
+
+func dataPath(id string) string {
+	return filepath.Join(Dir(), id)
+}
+
+func infoPath(id string) string {
+	return dataPath(id) + infoExt
+}
+
+// expiration is the duration after the last write, the upload is deleted after it
+func expiration() time.Duration {
+	return time.Duration(setting.GetIntSetting(conf.TusExpiration, 24)) * time.Hour
+}
+
+func save(u *Upload) error {
+	data, err := utils.Json.Marshal(u)
+	if err != nil {
+		return errors.Wrap(err, "failed marshal upload info")
+	}
+	// write to a temp file and rename, so that the info is never half written
+	tmp := infoPath(u.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "failed write upload info")
+	}
+	return errors.Wrap(os.Rename(tmp, infoPath(u.ID)), "failed write upload info")
+}
+
+// Create the upload with an empty data file, the ID and Expires of it are set
+func Create(u *Upload) error {
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return errors.Wrap(err, "failed create tus dir")
+	}
+	u.ID = uuid.NewString()
+	u.Offset = 0
+	u.Expires = time.Now().Add(expiration())
+	f, err := os.OpenFile(dataPath(u.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed create upload file")
+	}
+	_ = f.Close()
+	if err := save(u); err != nil {
+		_ = os.Remove(dataPath(u.ID))
+		return err
+	}
+	return nil
+}
+
+// Get the upload by id, the expired upload is removed and not found
+func Get(id string) (*Upload, error) {
+	// the id is used as file name, so it shouldn't be a path
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, errors.WithStack(ErrNotFound)
+	}
+	data, err := os.ReadFile(infoPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.WithStack(ErrNotFound)
+		}
+		return nil, errors.Wrap(err, "failed read upload info")
+	}
+	var u Upload
+	if err := utils.Json.Unmarshal(data, &u); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshal upload info")
+	}
+	if time.Now().After(u.Expires) {
+		_ = Remove(id)
+		return nil, errors.WithStack(ErrNotFound)
+	}
+	return &u, nil
+}
+
+// Write the bytes from offset, which should be the received size of the upload.
+// the bytes received before the reader broken are kept, so that the client can resume
+func Write(id string, offset int64, r io.Reader) (*Upload, error) {
+	if !lock(id) {
+		return nil, errors.WithStack(ErrLocked)
+	}
+	defer unlock(id)
+	u, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != u.Offset {
+		return u, errors.WithStack(ErrOffsetMismatch)
+	}
+	f, err := os.OpenFile(dataPath(id), os.O_WRONLY, 0600)
+	if err != nil {
+		return u, errors.Wrap(err, "failed open upload file")
+	}
+	defer f.Close()
+	// drop the bytes written after the last saved offset
+	if err := f.Truncate(offset); err != nil {
+		return u, errors.Wrap(err, "failed truncate upload file")
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return u, errors.Wrap(err, "failed seek upload file")
+	}
+	n, copyErr := io.Copy(f, io.LimitReader(r, u.Size-offset))
+	u.Offset += n
+	u.Expires = time.Now().Add(expiration())
+	if err := save(u); err != nil {
+		return u, err
+	}
+	if copyErr != nil {
+		return u, errors.Wrap(copyErr, "failed receive upload data")
+	}
+	return u, nil
+}
+
+// Complete move the data file of the finished upload into the temp dir and pass it to put,
+// the upload is deleted if put succeeds, otherwise it's kept so that the client can retry.
+// the file is managed as other temp files by put from then on
+func Complete(id string, put func(u *Upload, f *os.File) error) error {
+	if !lock(id) {
+		return errors.WithStack(ErrLocked)
+	}
+	defer unlock(id)
+	u, err := Get(id)
+	if err != nil {
+		return err
+	}
+	if !u.Done() {
+		return errors.Errorf("upload is not finished, received %d of %d bytes", u.Offset, u.Size)
+	}
+	path := filepath.Join(conf.Conf.TempDir, "file-tus-"+id)
+	if err := os.Rename(dataPath(id), path); err != nil {
+		return errors.Wrap(err, "failed move upload file")
+	}
+	f, err := os.Open(path)
+	if err == nil {
+		err = put(u, f)
+	}
+	if err != nil {
+		_ = f.Close()
+		// the file may be removed by put, then the upload can't be retried
+		if os.Rename(path, dataPath(id)) != nil {
+			_ = Remove(id)
+		}
+		return err
+	}
+	_ = os.Remove(infoPath(id))
+	return nil
+}
+
+// Remove the upload with its data
+func Remove(id string) error {
+	err := os.Remove(infoPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed delete upload info")
+	}
+	err = os.Remove(dataPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed delete upload file")
+	}
+	return nil
+}
+
+// ClearExpired remove the expired uploads and the files without info
+func ClearExpired() {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read tus dir: %+v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, infoExt) {
+			// the expired upload is removed while getting
+			_, _ = Get(strings.TrimSuffix(name, infoExt))
+			continue
+		}
+		if _, err := os.Stat(infoPath(name)); !os.IsNotExist(err) {
+			continue
+		}
+		// a data file is created just before its info, so skip the new ones
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(Dir(), name)); err != nil {
+			log.Errorf("failed delete tus file: %+v", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package tus
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+}
+
+func TestUpload(t *testing.T) {
+	conf.Conf.TempDir = t.TempDir()
+	u := &Upload{Path: "/local/a.txt", Size: 10, Creator: "admin"}
+	if err := Create(u); err != nil {
+		t.Fatalf("failed create: %+v", err)
+	}
+	// the connection is broken after 4 bytes
+	broken := io.MultiReader(strings.NewReader("0123"), iotest.ErrReader(errors.New("broken")))
+	if _, err := Write(u.ID, 0, broken); err == nil {
+		t.Errorf("expected error of broken reader")
+	}
+	if u, _ = Get(u.ID); u.Offset != 4 {
+		t.Fatalf("expected the received 4 bytes kept, but got %d", u.Offset)
+	}
+	if _, err := Write(u.ID, 2, strings.NewReader("23456789")); !errors.Is(err, ErrOffsetMismatch) {
+		t.Errorf("expected offset mismatch, but got %v", err)
+	}
+	// the bytes exceeding the size are ignored
+	u, err := Write(u.ID, 4, strings.NewReader("456789extra"))
+	if err != nil || !u.Done() {
+		t.Fatalf("expected done, but got %+v %+v", u, err)
+	}
+	if err := Complete(u.ID, func(u *Upload, f *os.File) error {
+		return errors.New("failed put")
+	}); err == nil {
+		t.Errorf("expected error of put")
+	}
+	// the upload is kept after put failed, so it can be retried
+	var content []byte
+	err = Complete(u.ID, func(u *Upload, f *os.File) error {
+		defer f.Close()
+		content, err = ioutil.ReadAll(f)
+		return err
+	})
+	if err != nil || string(content) != "0123456789" {
+		t.Errorf("unexpected content: %s %+v", content, err)
+	}
+	if _, err := Get(u.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the completed upload deleted, but got %v", err)
+	}
+}
+
+func TestClearExpired(t *testing.T) {
+	conf.Conf.TempDir = t.TempDir()
+	expired, alive := &Upload{Size: 10}, &Upload{Size: 10}
+	for _, u := range []*Upload{expired, alive} {
+		if err := Create(u); err != nil {
+			t.Fatalf("failed create: %+v", err)
+		}
+	}
+	expired.Expires = time.Now().Add(-time.Minute)
+	if err := save(expired); err != nil {
+		t.Fatal(err)
+	}
+	ClearExpired()
+	if _, err := os.Stat(dataPath(expired.ID)); !os.IsNotExist(err) {
+		t.Errorf("expected the expired upload deleted, but got %v", err)
+	}
+	if _, err := Get(alive.ID); err != nil {
+		t.Errorf("expected the alive upload kept, but got %+v", err)
+	}
+}
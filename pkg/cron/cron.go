@@ -0,0 +1,155 @@
+// Package cron parse the standard cron expressions and compute the next time they match.
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule is a parsed cron expression of 5 fields: minute, hour, day of month, month and day of week.
+// each field accepts *, a number, a range a-b, a step */n or a-b/n, and a list of them separated by commas
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// the day matches if either dom or dow matches when both of them are restricted
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max int
+}
+
+var fields = []bounds{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 is Sunday
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse the cron expression, descriptors such as @daily are supported too
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[spec]; ok {
+		spec = d
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, errors.Errorf("expected %d fields, but got %d: %s", len(fields), len(parts), spec)
+	}
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed parse field [%s]", part)
+		}
+		bits[i] = b
+	}
+	// 7 is also Sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	max := b.max
+	// allow 7 as Sunday
+	if b.max == 6 {
+		max = 7
+	}
+	for _, expr := range strings.Split(field, ",") {
+		rangeAndStep := strings.SplitN(expr, "/", 2)
+		start, end := b.min, b.max
+		if rangeAndStep[0] != "*" {
+			lowAndHigh := strings.SplitN(rangeAndStep[0], "-", 2)
+			var err error
+			if start, err = strconv.Atoi(lowAndHigh[0]); err != nil {
+				return 0, errors.WithStack(err)
+			}
+			end = start
+			if len(lowAndHigh) == 2 {
+				if end, err = strconv.Atoi(lowAndHigh[1]); err != nil {
+					return 0, errors.WithStack(err)
+				}
+			} else if len(rangeAndStep) == 2 {
+				// a/n means a-max/n
+				end = b.max
+			}
+		}
+		step := 1
+		if len(rangeAndStep) == 2 {
+			var err error
+			if step, err = strconv.Atoi(rangeAndStep[1]); err != nil {
+				return 0, errors.WithStack(err)
+			}
+			if step <= 0 {
+				return 0, errors.Errorf("step must be positive: %d", step)
+			}
+		}
+		if start < b.min || end > max || start > end {
+			return 0, errors.Errorf("out of range [%d, %d]: %s", b.min, max, expr)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// Next return the first time after t that matches the schedule, the zero time is
+// returned if there is no such time in 5 years, such as 30th of February
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,41 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2022, 6, 30, 10, 15, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2022, 6, 30, 10, 16, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2022, 6, 30, 10, 20, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2022, 7, 1, 3, 0, 0, 0, time.UTC)},
+		{"30 2 * * 0", time.Date(2022, 7, 3, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2022, 6, 30, 13, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.spec)
+		if err != nil {
+			t.Fatalf("failed parse [%s]: %+v", test.spec, err)
+		}
+		if next := s.Next(from); !next.Equal(test.next) {
+			t.Errorf("expected next of [%s] is %s, but got %s", test.spec, test.next, next)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected error of [%s]", spec)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Package download download files over http with multiple connections, it can be resumed
+// from the state saved beside the file and verify the checksum after downloaded.
+package download
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/ratelimit"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	stateSuffix   = ".download"
+	saveInterval  = time.Second
+	defaultName   = "download"
+	defaultConns  = 4
+	minPartSize   = 1024 * 1024
+	probeRangeEnd = 0
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Downloader download files, it's safe to be used by many downloads at the same time
+type Downloader struct {
+	Client *http.Client
+	// Connections is the max number of connections of a download
+	Connections int
+	// MinPartSize is the min bytes downloaded by a connection
+	MinPartSize int64
+	// Limiters limit the bandwidth of all the downloads
+	Limiters []*ratelimit.Limiter
+}
+
+// Progress is called with the downloaded bytes and the total bytes, the total is -1 if it's unknown
+type Progress func(completed, total int64)
+
+// state is saved beside the file being downloaded, so that it can be resumed
+type state struct {
+	URL    string  `json:"url"`
+	Total  int64   `json:"total"`
+	Ranged bool    `json:"ranged"`
+	Parts  []*part `json:"parts"`
+}
+
+type part struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"` // exclusive
+	Done  int64 `json:"done"`
+}
+
+func (p *part) done() int64 {
+	return atomic.LoadInt64(&p.Done)
+}
+
+// Download the url into dir, the name of the file is decided by the response.
+// if a download of the same url was stopped in dir, it continues from where it stopped.
+// checksum is in the form of algo=hex, such as sha-256=..., it's verified if not empty
+func (d *Downloader) Download(ctx context.Context, rawURL, dir, checksum string, progress Progress) (string, error) {
+	if progress == nil {
+		progress = func(completed, total int64) {}
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", errors.WithStack(err)
+	}
+	name, total, ranged, err := d.probe(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(dir, name)
+	statePath := filepath.Join(dir, "."+name+stateSuffix)
+	s := d.loadState(statePath, rawURL, total)
+	if fi, err := os.Stat(filePath); err != nil || fi.Size() != total {
+		// the file downloaded before is gone
+		s = nil
+	}
+	if s == nil || !ranged {
+		s = d.newState(rawURL, total, ranged)
+	}
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	err = d.download(ctx, f, s, statePath, progress)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = errors.WithStack(closeErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	_ = os.Remove(statePath)
+	if checksum != "" {
+		if err := verify(filePath, checksum); err != nil {
+			_ = os.Remove(filePath)
+			return "", err
+		}
+	}
+	return filePath, nil
+}
+
+// probe get the name and size of the file, and whether the server supports ranges
+func (d *Downloader) probe(ctx context.Context, rawURL string) (string, int64, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", 0, false, errors.WithStack(err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", probeRangeEnd))
+	res, err := d.client().Do(req)
+	if err != nil {
+		return "", 0, false, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return "", 0, false, errors.Errorf("failed probe %s: %s", rawURL, res.Status)
+	}
+	name := fileName(res)
+	if res.StatusCode == http.StatusPartialContent {
+		// bytes 0-0/total
+		cr := res.Header.Get("Content-Range")
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return name, total, true, nil
+			}
+		}
+	}
+	return name, res.ContentLength, false, nil
+}
+
+func (d *Downloader) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return http.DefaultClient
+}
+
+// fileName get the name from Content-Disposition or the path of the url
+func fileName(res *http.Response) string {
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(params["filename"]); params["filename"] != "" && name != "/" && name != "." {
+			return name
+		}
+	}
+	u := res.Request.URL
+	if name, err := url.PathUnescape(path.Base(u.Path)); err == nil && name != "/" && name != "." {
+		return name
+	}
+	return defaultName
+}
+
+func (d *Downloader) newState(rawURL string, total int64, ranged bool) *state {
+	s := &state{URL: rawURL, Total: total, Ranged: ranged && total > 0}
+	if !ranged || total <= 0 {
+		// download in one connection till EOF
+		s.Parts = []*part{{Start: 0, End: total}}
+		return s
+	}
+	conns := d.Connections
+	if conns <= 0 {
+		conns = defaultConns
+	}
+	minSize := d.MinPartSize
+	if minSize <= 0 {
+		minSize = minPartSize
+	}
+	size := (total + int64(conns) - 1) / int64(conns)
+	if size < minSize {
+		size = minSize
+	}
+	for start := int64(0); start < total; start += size {
+		end := start + size
+		if end > total {
+			end = total
+		}
+		s.Parts = append(s.Parts, &part{Start: start, End: end})
+	}
+	return s
+}
+
+// loadState load the state of the stopped download, it's nil if it's not the same file
+func (d *Downloader) loadState(statePath, rawURL string, total int64) *state {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return nil
+	}
+	var s state
+	if err := utils.Json.Unmarshal(data, &s); err != nil || s.URL != rawURL || s.Total != total || !s.Ranged {
+		return nil
+	}
+	return &s
+}
+
+func (s *state) save(statePath string) error {
+	// the parts are being downloaded, so they are copied first
+	snapshot := *s
+	snapshot.Parts = make([]*part, len(s.Parts))
+	for i, p := range s.Parts {
+		snapshot.Parts[i] = &part{Start: p.Start, End: p.End, Done: p.done()}
+	}
+	data, err := utils.Json.Marshal(snapshot)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(statePath, data, 0666))
+}
+
+func (s *state) completed() int64 {
+	var n int64
+	for _, p := range s.Parts {
+		n += p.done()
+	}
+	return n
+}
+
+func (d *Downloader) download(ctx context.Context, f *os.File, s *state, statePath string, progress Progress) error {
+	// the stale bytes of the last download are dropped if it can't be resumed
+	size := s.Total
+	if size < 0 {
+		size = 0
+	}
+	if err := f.Truncate(size); err != nil {
+		return errors.WithStack(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for _, p := range s.Parts {
+		if s.Total > 0 && p.Start+p.done() >= p.End {
+			continue
+		}
+		wg.Add(1)
+		go func(p *part) {
+			defer wg.Done()
+			if err := d.downloadPart(ctx, f, s, p); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(p)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			progress(s.completed(), s.Total)
+			if s.Ranged {
+				_ = s.save(statePath)
+			}
+		case <-done:
+			progress(s.completed(), s.Total)
+			if firstErr != nil {
+				if s.Ranged {
+					_ = s.save(statePath)
+				}
+				return firstErr
+			}
+			return nil
+		}
+	}
+}
+
+// downloadPart download the rest of the part and write it at its offset
+func (d *Downloader) downloadPart(ctx context.Context, f *os.File, s *state, p *part) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if s.Ranged {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", p.Start+p.done(), p.End-1))
+	}
+	res, err := d.client().Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return errors.Errorf("failed download %s: %s", s.URL, res.Status)
+	}
+	if s.Ranged && res.StatusCode != http.StatusPartialContent && p.Start+p.done() != 0 {
+		return errors.Errorf("failed download %s: range not supported", s.URL)
+	}
+	r := ratelimit.NewReader(ctx, res.Body, d.Limiters...)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			offset := p.Start + p.done()
+			if s.Total > 0 && offset+int64(n) > p.End {
+				n = int(p.End - offset)
+			}
+			if _, err := f.WriteAt(buf[:n], offset); err != nil {
+				return errors.WithStack(err)
+			}
+			atomic.AddInt64(&p.Done, int64(n))
+			if s.Total > 0 && p.Start+p.done() >= p.End {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			if s.Total > 0 && p.Start+p.done() < p.End {
+				return errors.WithStack(io.ErrUnexpectedEOF)
+			}
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+// verify the checksum of the file, which is in the form of algo=hex
+func verify(filePath, checksum string) error {
+	i := strings.Index(checksum, "=")
+	if i < 0 {
+		return errors.Errorf("invalid checksum: %s", checksum)
+	}
+	var h hash.Hash
+	switch strings.ToLower(strings.ReplaceAll(checksum[:i], "-", "")) {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return errors.Errorf("unsupported checksum algorithm: %s", checksum[:i])
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.WithStack(err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, checksum[i+1:]) {
+		return errors.Wrapf(ErrChecksumMismatch, "expected %s, but got %s", checksum[i+1:], sum)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func serve(t *testing.T, data []byte, ranged bool, requests *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			atomic.AddInt32(requests, 1)
+		}
+		if !ranged {
+			_, _ = w.Write(data)
+			return
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	return data
+}
+
+func TestDownload_MultiConnections(t *testing.T) {
+	data := randomData(1000*1000 + 7)
+	var requests int32
+	srv := serve(t, data, true, &requests)
+	dir := t.TempDir()
+	d := &Downloader{Connections: 4, MinPartSize: 1024}
+	filePath, err := d.Download(context.Background(), srv.URL+"/dir/file.bin", dir, "", nil)
+	if err != nil {
+		t.Fatalf("failed download: %+v", err)
+	}
+	if filepath.Base(filePath) != "file.bin" {
+		t.Errorf("expected file.bin, but got %s", filePath)
+	}
+	got, _ := os.ReadFile(filePath)
+	if !bytes.Equal(got, data) {
+		t.Errorf("the downloaded file is different")
+	}
+	// the probe and the 4 parts
+	if n := atomic.LoadInt32(&requests); n != 5 {
+		t.Errorf("expected 5 requests, but got %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".file.bin"+stateSuffix)); !os.IsNotExist(err) {
+		t.Errorf("expected the state to be removed")
+	}
+}
+
+func TestDownload_NoRange(t *testing.T) {
+	data := randomData(100 * 1024)
+	srv := serve(t, data, false, nil)
+	d := &Downloader{Connections: 4, MinPartSize: 1024}
+	filePath, err := d.Download(context.Background(), srv.URL+"/file.bin", t.TempDir(), "", nil)
+	if err != nil {
+		t.Fatalf("failed download: %+v", err)
+	}
+	got, _ := os.ReadFile(filePath)
+	if !bytes.Equal(got, data) {
+		t.Errorf("the downloaded file is different")
+	}
+}
+
+func TestDownload_Resume(t *testing.T) {
+	data := randomData(200 * 1024)
+	srv := serve(t, data, true, nil)
+	dir := t.TempDir()
+	// the first half of every part was downloaded before
+	d := &Downloader{Connections: 2, MinPartSize: 1024}
+	s := d.newState(srv.URL+"/file.bin", int64(len(data)), true)
+	partial := make([]byte, len(data))
+	for _, p := range s.Parts {
+		p.Done = (p.End - p.Start) / 2
+		copy(partial[p.Start:p.Start+p.Done], data[p.Start:p.Start+p.Done])
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.bin"), partial, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.save(filepath.Join(dir, ".file.bin"+stateSuffix)); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	d.Client = &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	d.Connections = 1
+	filePath, err := d.Download(context.Background(), srv.URL+"/file.bin", dir, "", nil)
+	if err != nil {
+		t.Fatalf("failed download: %+v", err)
+	}
+	got, _ := os.ReadFile(filePath)
+	if !bytes.Equal(got, data) {
+		t.Errorf("the downloaded file is different")
+	}
+	for _, r := range ranges[1:] {
+		if strings.HasPrefix(r, "bytes=0-") {
+			t.Errorf("expected to resume, but got range %s", r)
+		}
+	}
+}
+
+func TestDownload_Checksum(t *testing.T) {
+	data := randomData(10 * 1024)
+	srv := serve(t, data, true, nil)
+	sum := sha256.Sum256(data)
+	d := &Downloader{}
+	if _, err := d.Download(context.Background(), srv.URL+"/file.bin", t.TempDir(), "sha-256="+hex.EncodeToString(sum[:]), nil); err != nil {
+		t.Errorf("failed download: %+v", err)
+	}
+	dir := t.TempDir()
+	_, err := d.Download(context.Background(), srv.URL+"/file.bin", dir, "sha-256="+strings.Repeat("0", 64), nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected checksum mismatch, but got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.bin")); !os.IsNotExist(err) {
+		t.Errorf("expected the mismatched file to be removed")
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
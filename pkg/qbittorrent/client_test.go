@@ -0,0 +1,135 @@
+package qbittorrent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// stub emulate the part of qBittorrent Web API used by the client
+type stub struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	torrents map[string]*TorrentInfo // by tag
+}
+
+func newStub(t *testing.T) (*stub, *httptest.Server) {
+	s := &stub{sessions: map[string]bool{}, torrents: map[string]*TorrentInfo{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/api/v2/auth/login" {
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+			_, _ = w.Write([]byte("Fails."))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "sid", Path: "/"})
+		s.sessions["sid"] = true
+		_, _ = w.Write([]byte("Ok."))
+		return
+	}
+	if cookie, err := r.Cookie("SID"); err != nil || !s.sessions[cookie.Value] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	find := func(hash string) *TorrentInfo {
+		for _, t := range s.torrents {
+			if t.Hash == hash {
+				return t
+			}
+		}
+		return nil
+	}
+	switch r.URL.Path {
+	case "/api/v2/app/version":
+		_, _ = w.Write([]byte("v4.4.3"))
+	case "/api/v2/torrents/add":
+		tag := r.FormValue("tags")
+		state := "downloading"
+		if r.FormValue("paused") == "true" {
+			state = "pausedDL"
+		}
+		s.torrents[tag] = &TorrentInfo{Hash: "hash-" + tag, Name: "file.bin", State: state, SavePath: r.FormValue("savepath"), Tags: tag, Size: 10}
+		_, _ = w.Write([]byte("Ok."))
+	case "/api/v2/torrents/info":
+		var infos []TorrentInfo
+		if t, ok := s.torrents[r.FormValue("tag")]; ok {
+			infos = append(infos, *t)
+		}
+		_ = json.NewEncoder(w).Encode(infos)
+	case "/api/v2/torrents/files":
+		t := find(r.FormValue("hash"))
+		if t == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]FileInfo{{Name: t.Name, Size: t.Size, Progress: 1}})
+	case "/api/v2/torrents/pause", "/api/v2/torrents/resume":
+		t := find(r.FormValue("hashes"))
+		if t == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		t.State = map[bool]string{true: "pausedDL", false: "downloading"}[strings.HasSuffix(r.URL.Path, "pause")]
+	case "/api/v2/torrents/delete":
+		if t := find(r.FormValue("hashes")); t != nil {
+			delete(s.torrents, t.Tags)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient(t *testing.T) {
+	s, srv := newStub(t)
+	if _, err := New(strings.Replace(srv.URL, "http://", "http://admin:wrong@", 1)); err == nil {
+		t.Fatalf("expected failed login")
+	}
+	c, err := New(strings.Replace(srv.URL, "http://", "http://admin:secret@", 1) + "/")
+	if err != nil {
+		t.Fatalf("failed login: %+v", err)
+	}
+	if version, err := c.Version(); err != nil || version != "v4.4.3" {
+		t.Errorf("unexpected version: %s %+v", version, err)
+	}
+	if err := c.AddURL("magnet:?xt=urn:btih:abc", "/tmp/dir", "tag1", true); err != nil {
+		t.Fatalf("failed add: %+v", err)
+	}
+	info, err := c.GetInfo("tag1")
+	if err != nil {
+		t.Fatalf("failed get info: %+v", err)
+	}
+	if info.State != "pausedDL" || info.SavePath != "/tmp/dir" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	// the session is expired, it should log in again
+	s.mu.Lock()
+	s.sessions = map[string]bool{}
+	s.mu.Unlock()
+	if err := c.Resume(info.Hash); err != nil {
+		t.Fatalf("failed resume: %+v", err)
+	}
+	if info, _ := c.GetInfo("tag1"); info.State != "downloading" {
+		t.Errorf("expected downloading, but got %s", info.State)
+	}
+	files, err := c.GetFiles(info.Hash)
+	if err != nil || len(files) != 1 || files[0].Name != "file.bin" {
+		t.Errorf("unexpected files: %+v %+v", files, err)
+	}
+	if err := c.Delete(info.Hash, false); err != nil {
+		t.Fatalf("failed delete: %+v", err)
+	}
+	if _, err := c.GetInfo("tag1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, but got %v", err)
+	}
+}
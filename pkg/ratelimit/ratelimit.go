@@ -0,0 +1,180 @@
+// Package ratelimit limit the bandwidth of readers and writers by token buckets.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// the max bytes to read or write at a time, so that the limited streams are smooth
+const maxChunk = 32 * 1024
+
+// Limiter is a token bucket filled with rate bytes per second, its burst is the bytes of one second.
+// the bytes taken beyond the tokens are owed, and the next ones wait until the debt is paid,
+// so that a big chunk won't be rejected. it can be shared by many streams and changed at runtime
+type Limiter struct {
+	mu     sync.Mutex
+	rate   int64 // bytes per second, 0 is unlimited
+	tokens float64
+	last   time.Time
+	clock  Clock
+}
+
+// Clock is the time source of limiters, a fake one is used in tests
+type Clock interface {
+	Now() time.Time
+	// Sleep wait for d, it returns the error of ctx if ctx is done before
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func New(rate int64) *Limiter {
+	return NewWithClock(rate, realClock{})
+}
+
+func NewWithClock(rate int64, clock Clock) *Limiter {
+	return &Limiter{rate: rate, tokens: float64(rate), last: clock.Now(), clock: clock}
+}
+
+// SetRate change the bytes per second, 0 or negative is unlimited
+func (l *Limiter) SetRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate == l.rate {
+		return
+	}
+	l.rate = rate
+	if l.tokens > float64(rate) {
+		l.tokens = float64(rate)
+	}
+}
+
+func (l *Limiter) Rate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// WaitN take n tokens, it blocks until they are available or ctx is done
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := l.clock.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return l.clock.Sleep(ctx, wait)
+}
+
+// chunk return the bytes to transfer at a time, it's less than the smallest burst
+func chunk(limiters []*Limiter) int {
+	n := maxChunk
+	for _, l := range limiters {
+		if rate := l.Rate(); rate > 0 && rate < int64(n) {
+			n = int(rate)
+		}
+	}
+	return n
+}
+
+func wait(ctx context.Context, limiters []*Limiter, n int) error {
+	for _, l := range limiters {
+		if err := l.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type reader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if c := chunk(r.limiters); len(p) > c {
+		p = p[:c]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if err := wait(r.ctx, r.limiters, n); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// NewReader return a reader limited by all the limiters
+func NewReader(ctx context.Context, r io.Reader, limiters ...*Limiter) io.Reader {
+	if len(limiters) == 0 {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, limiters: limiters}
+}
+
+type writer struct {
+	ctx      context.Context
+	w        io.Writer
+	limiters []*Limiter
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := chunk(w.limiters)
+		if n > len(p) {
+			n = len(p)
+		}
+		if err := wait(w.ctx, w.limiters, n); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(p[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// NewWriter return a writer limited by all the limiters
+func NewWriter(ctx context.Context, w io.Writer, limiters ...*Limiter) io.Writer {
+	if len(limiters) == 0 {
+		return w
+	}
+	return &writer{ctx: ctx, w: w, limiters: limiters}
+}
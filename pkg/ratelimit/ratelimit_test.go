@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock moves forward only when it sleeps, so the time taken is exact
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// elapsed check the time taken since start is about expected
+func elapsed(t *testing.T, c *fakeClock, start time.Time, expected time.Duration) {
+	if d := c.Now().Sub(start); d < expected-10*time.Millisecond || d > expected+10*time.Millisecond {
+		t.Errorf("expected %s, but took %s", expected, d)
+	}
+}
+
+func TestReader(t *testing.T) {
+	clock := newFakeClock()
+	data := bytes.Repeat([]byte("0"), 30*1024)
+	// the first 10KiB is the burst, the rest takes 2 seconds
+	r := NewReader(context.Background(), bytes.NewReader(data), NewWithClock(10*1024, clock))
+	start := clock.Now()
+	read, err := ioutil.ReadAll(r)
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected read: %v", err)
+	}
+	elapsed(t, clock, start, 2*time.Second)
+}
+
+func TestWriter_Shared(t *testing.T) {
+	clock := newFakeClock()
+	l := NewWithClock(10*1024, clock)
+	start := clock.Now()
+	// the two writers share the bandwidth, the second one waits for the burst taken by the first
+	for i := 0; i < 2; i++ {
+		w := NewWriter(context.Background(), ioutil.Discard, l)
+		if _, err := w.Write(make([]byte, 10*1024)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	elapsed(t, clock, start, time.Second)
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	l := NewWithClock(1024, newFakeClock())
+	_ = l.WaitN(context.Background(), 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitN(ctx, 10*1024); err == nil {
+		t.Errorf("expected error after ctx done")
+	}
+	l.SetRate(0)
+	if err := l.WaitN(context.Background(), 1<<30); err != nil {
+		t.Errorf("unlimited limiter shouldn't wait: %v", err)
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := (realClock{}).Sleep(ctx, time.Hour); err == nil {
+		t.Errorf("expected error after ctx done")
+	}
+}
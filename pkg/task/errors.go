@@ -3,5 +3,8 @@ package task
 import "errors"
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskNotRetryable = errors.New("task can't be retried")
+	ErrTaskNotPausable  = errors.New("task can't be paused")
+	ErrTaskNotPaused    = errors.New("task is not paused")
 )
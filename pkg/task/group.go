@@ -0,0 +1,138 @@
+package task
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Stats is the aggregate of the files in a task and its descendants
+type Stats struct {
+	Files       int   `json:"files"`
+	DoneFiles   int   `json:"done_files"`
+	FailedFiles int   `json:"failed_files"`
+	Bytes       int64 `json:"bytes"`
+	DoneBytes   int64 `json:"done_bytes"`
+}
+
+func (t *Task[K]) GetParent() *Task[K] {
+	return t.parent
+}
+
+func (t *Task[K]) GetChildren() []*Task[K] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	children := make([]*Task[K], len(t.children))
+	copy(children, t.children)
+	return children
+}
+
+func (t *Task[K]) addChild(child *Task[K]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	child.parent = t
+	t.children = append(t.children, child)
+}
+
+// Stats count the files and bytes of the task, a task which is not a Group is a file
+func (t *Task[K]) Stats() Stats {
+	if !t.Group {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		s := Stats{Files: 1, Bytes: t.Size}
+		switch t.state {
+		case SUCCEEDED:
+			s.DoneFiles, s.DoneBytes = 1, t.Size
+		case ERRORED, CANCELED:
+			s.FailedFiles = 1
+		default:
+			s.DoneBytes = t.Size * int64(t.progress) / 100
+		}
+		return s
+	}
+	var s Stats
+	for _, child := range t.GetChildren() {
+		cs := child.Stats()
+		s.Files += cs.Files
+		s.DoneFiles += cs.DoneFiles
+		s.FailedFiles += cs.FailedFiles
+		s.Bytes += cs.Bytes
+		s.DoneBytes += cs.DoneBytes
+	}
+	return s
+}
+
+// settle decide the final state of the task whose Func succeeded by its children,
+// it's WAITING if some children are undone. it returns whether the task is settled,
+// the task in other state than from is skipped, so that it's settled only once
+func (t *Task[K]) settle(from string) bool {
+	t.mu.Lock()
+	if t.state != from {
+		t.mu.Unlock()
+		return false
+	}
+	failed, canceled := 0, 0
+	for _, child := range t.children {
+		switch child.GetState() {
+		case SUCCEEDED:
+		case ERRORED:
+			failed++
+		case CANCELED:
+			canceled++
+		default:
+			t.state = WAITING
+			t.mu.Unlock()
+			return false
+		}
+	}
+	switch {
+	case errors.Is(t.Ctx.Err(), context.Canceled) && !t.pausing:
+		t.state = CANCELED
+	case failed > 0:
+		t.Error = errors.Errorf("%d of %d children failed", failed, len(t.children))
+		t.state = ERRORED
+	case canceled > 0:
+		t.Error = errors.Errorf("%d of %d children canceled", canceled, len(t.children))
+		t.state = ERRORED
+	default:
+		t.state = SUCCEEDED
+	}
+	succeeded := t.state == SUCCEEDED
+	t.mu.Unlock()
+	if succeeded && t.callback != nil {
+		t.callback(t)
+	}
+	return true
+}
+
+// depth is the number of the ancestors of the task
+func (t *Task[K]) depth() int {
+	d := 0
+	for p := t.GetParent(); p != nil; p = p.GetParent() {
+		d++
+	}
+	return d
+}
+
+// retryable check whether the task failed, or it's waiting for the children while some of them failed
+func (t *Task[K]) retryable() bool {
+	switch t.GetState() {
+	case ERRORED, CANCELED:
+		return true
+	case WAITING:
+		for _, child := range t.GetChildren() {
+			if child.retryable() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notifyParent settle the WAITING parent after the task finished
+func (t *Task[K]) notifyParent() {
+	if p := t.parent; p != nil && p.settle(WAITING) {
+		p.save()
+		p.notifyParent()
+	}
+}
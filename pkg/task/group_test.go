@@ -0,0 +1,108 @@
+package task
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func TestTask_Group(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var failed int32 = 1
+	parentID := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name:  "parent",
+		Group: true,
+		Func: func(parent *Task[uint64]) error {
+			for i := 0; i < 3; i++ {
+				i := i
+				tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+					Name: "child",
+					Size: 100,
+					Func: func(task *Task[uint64]) error {
+						time.Sleep(time.Millisecond * 50)
+						if i == 0 && atomic.LoadInt32(&failed) == 1 {
+							return errors.New("test error")
+						}
+						return nil
+					},
+				}))
+			}
+			return nil
+		},
+	}))
+	parent := tm.MustGet(parentID)
+	time.Sleep(time.Millisecond * 20)
+	if parent.GetState() != WAITING {
+		t.Errorf("parent should be waiting: %s", parent.GetState())
+	}
+	time.Sleep(time.Millisecond * 200)
+	if parent.GetState() != ERRORED {
+		t.Fatalf("parent should be errored by child: %s", parent.GetState())
+	}
+	if s := parent.Stats(); s.Files != 3 || s.DoneFiles != 2 || s.FailedFiles != 1 || s.Bytes != 300 || s.DoneBytes != 200 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+
+	// only the failed child is retried
+	atomic.StoreInt32(&failed, 0)
+	if err := tm.Retry(parentID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 200)
+	if parent.GetState() != SUCCEEDED || len(parent.GetChildren()) != 3 {
+		t.Fatalf("parent should be succeeded: %s %s", parent.GetState(), parent.GetErrMsg())
+	}
+	if parent.GetProgress() != 100 {
+		t.Errorf("unexpected progress: %d", parent.GetProgress())
+	}
+	if len(tm.GetAll()) != 4 {
+		t.Errorf("unexpected tasks count: %d", len(tm.GetAll()))
+	}
+	tm.Remove(parentID)
+	if len(tm.GetAll()) != 0 {
+		t.Errorf("children should be removed with parent")
+	}
+}
+
+func TestTask_GroupCancel(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	parentID := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name:  "parent",
+		Group: true,
+		Func: func(parent *Task[uint64]) error {
+			for i := 0; i < 5; i++ {
+				tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+					Name: "child",
+					Func: func(task *Task[uint64]) error {
+						for !utils.IsCanceled(task.Ctx) {
+							time.Sleep(time.Millisecond)
+						}
+						return nil
+					},
+				}))
+			}
+			return nil
+		},
+	}))
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Cancel(parentID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	parent := tm.MustGet(parentID)
+	if parent.GetState() != CANCELED {
+		t.Errorf("parent should be canceled: %s", parent.GetState())
+	}
+	for _, child := range parent.GetChildren() {
+		if child.GetState() != CANCELED {
+			t.Errorf("child should be canceled: %s", child.GetState())
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package task
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
@@ -8,39 +13,268 @@ import (
 )
 
 type Manager[K comparable] struct {
-	workerC  chan struct{}
-	curID    K
-	updateID func(*K)
-	tasks    generic_sync.MapOf[K, *Task[K]]
+	workers     workers[K]
+	idMu        sync.Mutex // protect curID, tasks may be submitted by the running tasks concurrently
+	curID       K
+	updateID    func(*K)
+	tasks       generic_sync.MapOf[K, *Task[K]]
+	store       Store
+	resumers    map[string]Resumer[K]
+	watchers    []func(task *Task[K])
+	retryPolicy *RetryPolicy
 }
 
 func (tm *Manager[K]) Submit(task *Task[K]) K {
 	if tm.updateID != nil {
-		task.ID = tm.curID
-		tm.updateID(&task.ID)
+		task.ID = tm.nextID()
 	}
-	tm.tasks.Store(task.ID, task)
+	tm.add(task)
+	task.save()
 	tm.do(task)
 	return task.ID
 }
 
+// nextID allocate an unused id, the ids of recovered tasks are skipped
+func (tm *Manager[K]) nextID() K {
+	tm.idMu.Lock()
+	defer tm.idMu.Unlock()
+	for {
+		tm.updateID(&tm.curID)
+		if _, ok := tm.tasks.Load(tm.curID); !ok {
+			return tm.curID
+		}
+	}
+}
+
+// SubmitChild submit the task as a child of the parent, the parent is finished after all its children
+func (tm *Manager[K]) SubmitChild(parent, child *Task[K]) K {
+	if child.Creator == "" {
+		child.Creator = parent.Creator
+	}
+	if child.Priority == 0 {
+		child.Priority = parent.GetPriority()
+	}
+	parent.addChild(child)
+	return tm.Submit(child)
+}
+
+// add the task to the manager and bind it to the store
+func (tm *Manager[K]) add(task *Task[K]) {
+	tm.tasks.Store(task.ID, task)
+	if task.Retry == nil {
+		task.Retry = tm.retryPolicy
+	}
+	task.watch = func() {
+		for _, watch := range tm.watchers {
+			watch(task)
+		}
+	}
+	if tm.store != nil {
+		task.persist = func() {
+			if err := tm.store.Save(task.record()); err != nil {
+				log.Errorf("failed save task [%s]: %+v", task.Name, err)
+			}
+		}
+	}
+}
+
+// Watch register a func called when a task changed, such as its state, status or progress
+func (tm *Manager[K]) Watch(watch func(task *Task[K])) {
+	tm.watchers = append(tm.watchers, watch)
+}
+
+// SetRetryPolicy set the default policy to retry the failed tasks automatically,
+// it should be called before any task submitted
+func (tm *Manager[K]) SetRetryPolicy(policy *RetryPolicy) {
+	tm.retryPolicy = policy
+}
+
+// SetStore set the store to persist tasks, tasks submitted after it are persisted
+func (tm *Manager[K]) SetStore(store Store) {
+	tm.store = store
+}
+
+// RegisterResumer register the Resumer of tasks of the kind
+func (tm *Manager[K]) RegisterResumer(kind string, resumer Resumer[K]) {
+	tm.resumers[kind] = resumer
+}
+
+// Recover load the tasks from the store, undone tasks are resumed by the Resumer of their kind,
+// and finished tasks are kept as history
+func (tm *Manager[K]) Recover() error {
+	if tm.store == nil {
+		return nil
+	}
+	records, err := tm.store.List()
+	if err != nil {
+		return errors.WithMessage(err, "failed list tasks")
+	}
+	recovered := make(map[string]*Task[K], len(records))
+	tasks := make([]*Task[K], len(records))
+	for i, r := range records {
+		id, err := parseID[K](r.ID)
+		if err != nil {
+			log.Errorf("failed recover task [%s]: %+v", r.Name, err)
+			continue
+		}
+		tasks[i] = WithCancelCtx(&Task[K]{
+			ID:         id,
+			Name:       r.Name,
+			Kind:       r.Kind,
+			Args:       r.Args,
+			status:     r.Status,
+			progress:   r.Progress,
+			checkpoint: r.Checkpoint,
+			Group:      r.Group,
+			Size:       r.Size,
+			Creator:    r.Creator,
+			Priority:   r.Priority,
+			attempts:   r.Attempts,
+		})
+		recovered[r.ID] = tasks[i]
+	}
+	// link the children after all are loaded, the records may be in any order
+	for i, r := range records {
+		if parent, ok := recovered[r.ParentID]; ok && tasks[i] != nil {
+			parent.addChild(tasks[i])
+		}
+	}
+	var waiting []*Task[K]
+	for i, r := range records {
+		t := tasks[i]
+		if t == nil {
+			continue
+		}
+		if utils.SliceContains([]string{SUCCEEDED, CANCELING, CANCELED, ERRORED}, r.State) {
+			t.state = r.State
+			// it was canceled before it stopped
+			if t.state == CANCELING {
+				t.state = CANCELED
+			}
+			if r.Error != "" {
+				t.Error = errors.New(r.Error)
+			}
+			tm.add(t)
+			continue
+		}
+		// keep it paused until it's resumed by user, and the waiting one
+		// is settled by its children
+		if r.State == PAUSED || r.State == WAITING {
+			t.state = r.State
+			t.funcDone = r.State == WAITING
+		}
+		resumer, ok := tm.resumers[r.Kind]
+		if !ok {
+			err = errors.Errorf("no resumer of kind [%s]", r.Kind)
+		} else {
+			err = resumer(t)
+		}
+		if err != nil {
+			log.Errorf("failed resume task [%s]: %+v", r.Name, err)
+			t.state = ERRORED
+			t.Error = errors.WithMessage(err, "failed resume task")
+			tm.add(t)
+			t.save()
+			continue
+		}
+		if id, _ := parseID[K](r.ID); t.ID != id {
+			if err := tm.store.Delete(r.ID); err != nil {
+				log.Errorf("failed delete task [%s]: %+v", r.Name, err)
+			}
+		}
+		tm.add(t)
+		t.save()
+		switch t.state {
+		case PAUSED:
+		case WAITING:
+			waiting = append(waiting, t)
+		default:
+			tm.do(t)
+		}
+	}
+	// the children may have finished before the restart, settle from the deepest one
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return waiting[i].depth() > waiting[j].depth()
+	})
+	for _, t := range waiting {
+		if t.settle(WAITING) {
+			t.save()
+			t.notifyParent()
+		}
+	}
+	return nil
+}
+
 func (tm *Manager[K]) do(task *Task[K]) {
+	ticket := tm.workers.wait(task)
 	go func() {
 		log.Debugf("task [%s] waiting for worker", task.Name)
 		select {
-		case <-tm.workerC:
+		case <-ticket.start:
 			log.Debugf("task [%s] starting", task.Name)
 			task.run()
 			log.Debugf("task [%s] ended", task.Name)
+			if task.backoff > 0 {
+				tm.workers.release()
+				tm.retryLater(task)
+				return
+			}
+			task.notifyParent()
+			// return worker
+			tm.workers.release()
 		case <-task.Ctx.Done():
 			log.Debugf("task [%s] canceled", task.Name)
-			return
+			// it got a worker at the same time
+			if !tm.workers.leave(ticket) {
+				tm.workers.release()
+			}
+			task.stopped()
 		}
-		// return worker
-		tm.workerC <- struct{}{}
 	}()
 }
 
+// retryLater run the failed task again after the backoff, it can be paused or canceled meanwhile
+func (tm *Manager[K]) retryLater(task *Task[K]) {
+	log.Debugf("task [%s] retry in %s", task.Name, task.backoff)
+	timer := time.NewTimer(task.backoff)
+	defer timer.Stop()
+	task.backoff = 0
+	select {
+	case <-timer.C:
+		tm.do(task)
+	case <-task.Ctx.Done():
+		task.stopped()
+	}
+}
+
+// SetWorkers change the max number of running tasks, it takes effect immediately
+func (tm *Manager[K]) SetWorkers(max int) {
+	tm.workers.resize(max)
+}
+
+func (tm *Manager[K]) GetWorkers() int {
+	return tm.workers.size()
+}
+
+// SetPriority change the priority of the task and its children, the waiting task
+// with higher priority runs first
+func (tm *Manager[K]) SetPriority(tid K, priority int) error {
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	tm.setPriority(t, priority)
+	return nil
+}
+
+func (tm *Manager[K]) setPriority(t *Task[K], priority int) {
+	tm.workers.setPriority(t, priority)
+	t.save()
+	for _, child := range t.GetChildren() {
+		tm.setPriority(child, priority)
+	}
+}
+
 func (tm *Manager[K]) GetAll() []*Task[K] {
 	return tm.tasks.Values()
 }
@@ -54,12 +288,52 @@ func (tm *Manager[K]) MustGet(tid K) *Task[K] {
 	return task
 }
 
+// Retry run the task again, the failed children of a group are retried instead
+// of the group if the Func of it has been done
 func (tm *Manager[K]) Retry(tid K) error {
 	t, ok := tm.Get(tid)
 	if !ok {
 		return errors.WithStack(ErrTaskNotFound)
 	}
-	tm.do(t)
+	return tm.retry(t)
+}
+
+func (tm *Manager[K]) retry(t *Task[K]) error {
+	if t.Func == nil {
+		return errors.WithStack(ErrTaskNotRetryable)
+	}
+	// the Func of the pending or running task would be run twice
+	if !t.retryable() {
+		return errors.Wrapf(ErrTaskNotRetryable, "task is %s", t.GetState())
+	}
+	t.mu.Lock()
+	t.attempt = 0
+	funcDone := t.funcDone
+	t.mu.Unlock()
+	children := t.GetChildren()
+	if !funcDone || len(children) == 0 {
+		WithCancelCtx(t)
+		tm.do(t)
+		return nil
+	}
+	WithCancelCtx(t)
+	t.mu.Lock()
+	t.Error = nil
+	t.state = WAITING
+	t.mu.Unlock()
+	t.save()
+	for _, child := range children {
+		if child.retryable() {
+			if err := tm.retry(child); err != nil {
+				log.Errorf("failed retry task [%s]: %+v", child.Name, err)
+			}
+		}
+	}
+	// no child is retried
+	if t.settle(WAITING) {
+		t.save()
+		t.notifyParent()
+	}
 	return nil
 }
 
@@ -72,14 +346,70 @@ func (tm *Manager[K]) Cancel(tid K) error {
 	return nil
 }
 
+// Pause stop the task and its children and release their workers, they can be resumed later
+func (tm *Manager[K]) Pause(tid K) error {
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	return tm.pause(t)
+}
+
+func (tm *Manager[K]) pause(t *Task[K]) error {
+	for _, child := range t.GetChildren() {
+		// the finished children can't be paused
+		_ = tm.pause(child)
+	}
+	if t.GetState() == WAITING {
+		return nil
+	}
+	return t.pause()
+}
+
+// Resume run the paused task and its paused children again, they continue from their checkpoints
+func (tm *Manager[K]) Resume(tid K) error {
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	return tm.resume(t)
+}
+
+func (tm *Manager[K]) resume(t *Task[K]) error {
+	var err error
+	if t.GetState() != WAITING {
+		if err = t.resume(); err == nil {
+			tm.do(t)
+		}
+	}
+	for _, child := range t.GetChildren() {
+		// only the paused children are resumed
+		_ = tm.resume(child)
+	}
+	return err
+}
+
+// Remove the task and its children
 func (tm *Manager[K]) Remove(tid K) {
+	if t, ok := tm.Get(tid); ok {
+		for _, child := range t.GetChildren() {
+			tm.Remove(child.ID)
+		}
+	}
 	tm.tasks.Delete(tid)
+	if tm.store != nil {
+		if err := tm.store.Delete(fmt.Sprint(tid)); err != nil {
+			log.Errorf("failed delete task [%v]: %+v", tid, err)
+		}
+	}
 }
 
 // RemoveAll removes all tasks from the manager, this maybe shouldn't be used
 // because the task maybe still running.
 func (tm *Manager[K]) RemoveAll() {
-	tm.tasks.Clear()
+	for _, t := range tm.GetAll() {
+		tm.Remove(t.ID)
+	}
 }
 
 func (tm *Manager[K]) RemoveByStates(states ...string) {
@@ -103,7 +433,7 @@ func (tm *Manager[K]) GetByStates(states ...string) []*Task[K] {
 }
 
 func (tm *Manager[K]) ListUndone() []*Task[K] {
-	return tm.GetByStates(PENDING, RUNNING, CANCELING)
+	return tm.GetByStates(PENDING, RUNNING, CANCELING, PAUSED, WAITING)
 }
 
 func (tm *Manager[K]) ListDone() []*Task[K] {
@@ -112,11 +442,9 @@ func (tm *Manager[K]) ListDone() []*Task[K] {
 
 func NewTaskManager[K comparable](maxWorker int, updateID ...func(*K)) *Manager[K] {
 	tm := &Manager[K]{
-		tasks:   generic_sync.MapOf[K, *Task[K]]{},
-		workerC: make(chan struct{}, maxWorker),
-	}
-	for i := 0; i < maxWorker; i++ {
-		tm.workerC <- struct{}{}
+		tasks:    generic_sync.MapOf[K, *Task[K]]{},
+		workers:  workers[K]{max: maxWorker},
+		resumers: make(map[string]Resumer[K]),
 	}
 	if len(updateID) > 0 {
 		tm.updateID = updateID[0]
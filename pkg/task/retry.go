@@ -0,0 +1,69 @@
+package task
+
+import (
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Retryable is implemented by the errors of drivers to tell whether they are
+// transient, such as 5xx responses or rate limits of the storage backends
+type Retryable interface {
+	Retryable() bool
+}
+
+// IsRetryable check whether the task failed with err is worth retrying,
+// timeouts and broken connections are retryable besides Retryable errors
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r Retryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// RetryPolicy decide how the task failed with retryable errors is retried automatically
+type RetryPolicy struct {
+	// MaxAttempts is the max times to run the task, including the first one
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it's doubled for each next retry
+	Backoff time.Duration
+	// MaxBackoff is the max delay between retries
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     5 * time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// delay return the backoff before the next attempt of the failed one
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// Attempt is a failed run of the task, they are kept as the history of the task
+type Attempt struct {
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
@@ -0,0 +1,103 @@
+package task
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type retryableErr bool
+
+func (e retryableErr) Error() string {
+	return "test error"
+}
+
+func (e retryableErr) Retryable() bool {
+	return bool(e)
+}
+
+func TestTask_AutoRetry(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	tm.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond * 10})
+	var runs int32
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "transient",
+		Func: func(task *Task[uint64]) error {
+			if atomic.AddInt32(&runs, 1) < 3 {
+				return errors.WithStack(retryableErr(true))
+			}
+			return nil
+		},
+	}))
+	time.Sleep(time.Millisecond * 200)
+	task := tm.MustGet(id)
+	if task.GetState() != SUCCEEDED {
+		t.Fatalf("task should succeed after retries, but got %s: %s", task.GetState(), task.GetErrMsg())
+	}
+	if n := len(task.GetAttempts()); n != 2 {
+		t.Errorf("expected 2 failed attempts, but got %d", n)
+	}
+
+	atomic.StoreInt32(&runs, 0)
+	id = tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "permanent",
+		Func: func(task *Task[uint64]) error {
+			atomic.AddInt32(&runs, 1)
+			return errors.WithStack(retryableErr(false))
+		},
+	}))
+	time.Sleep(time.Millisecond * 100)
+	task = tm.MustGet(id)
+	if task.GetState() != ERRORED || atomic.LoadInt32(&runs) != 1 {
+		t.Errorf("task with non retryable error should not be retried, state: %s, runs: %d", task.GetState(), runs)
+	}
+}
+
+func TestTask_WillRunAgain(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	tm.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond * 10})
+	runAgain := make(chan bool, 3)
+	tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "transient",
+		Func: func(task *Task[uint64]) error {
+			err := errors.WithStack(retryableErr(true))
+			runAgain <- WillRunAgain(task.Ctx, err)
+			return err
+		},
+	}))
+	for i, expected := range []bool{true, true, false} {
+		select {
+		case r := <-runAgain:
+			if r != expected {
+				t.Errorf("expected run again %v after attempt %d, but got %v", expected, i+1, r)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("attempt %d not run", i+1)
+		}
+	}
+
+	canceled := WithCancelCtx(&Task[uint64]{Name: "canceled", Retry: DefaultRetryPolicy})
+	canceled.Cancel()
+	if canceled.WillRunAgain(errors.WithStack(retryableErr(true))) {
+		t.Errorf("canceled task should not run again")
+	}
+	if WillRunAgain(context.Background(), errors.WithStack(retryableErr(true))) {
+		t.Errorf("ctx not of a task should not run again")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := &RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if d := p.delay(attempt); d != expected {
+			t.Errorf("expected delay %s of attempt %d, but got %s", expected, attempt, d)
+		}
+	}
+}
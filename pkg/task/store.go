@@ -0,0 +1,80 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// the min interval of persisting a running task
+var persistInterval = 3 * time.Second
+
+// the min interval of notifying the watchers of a running task
+var watchInterval = 500 * time.Millisecond
+
+// Record is the persistent form of a task
+type Record struct {
+	ID         string
+	Name       string
+	Kind       string
+	Args       string
+	State      string
+	Status     string
+	Progress   int
+	Error      string
+	Checkpoint string
+	ParentID   string
+	Group      bool
+	Size       int64
+	Creator    string
+	Priority   int
+	Attempts   []Attempt
+}
+
+// Store persist the tasks of a manager, so that they survive restarts
+type Store interface {
+	Save(record Record) error
+	Delete(id string) error
+	List() ([]Record, error)
+}
+
+// Resumer rebuild the Func of an undone task by its Kind and Args after restart,
+// it may change other fields of the task too, such as ID
+type Resumer[K comparable] func(task *Task[K]) error
+
+func (t *Task[K]) record() Record {
+	var parentID string
+	if t.parent != nil {
+		parentID = fmt.Sprint(t.parent.ID)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Record{
+		ID:         fmt.Sprint(t.ID),
+		Name:       t.Name,
+		Kind:       t.Kind,
+		Args:       t.Args,
+		State:      t.state,
+		Status:     t.status,
+		Progress:   t.progress,
+		Error:      t.errMsg(),
+		Checkpoint: t.checkpoint,
+		ParentID:   parentID,
+		Group:      t.Group,
+		Size:       t.Size,
+		Creator:    t.Creator,
+		Priority:   t.Priority,
+		Attempts:   append([]Attempt(nil), t.attempts...),
+	}
+}
+
+func parseID[K comparable](s string) (K, error) {
+	var id K
+	if p, ok := any(&id).(*string); ok {
+		*p = s
+		return id, nil
+	}
+	_, err := fmt.Sscan(s, &id)
+	return id, errors.Wrapf(err, "invalid task id %s", s)
+}
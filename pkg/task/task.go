@@ -3,6 +3,10 @@ package task
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -14,6 +18,8 @@ var (
 	CANCELING = "canceling"
 	CANCELED  = "canceled"
 	ERRORED   = "errored"
+	PAUSED    = "paused"
+	WAITING   = "waiting" // the Func is done, waiting for the children
 )
 
 type Func[K comparable] func(task *Task[K]) error
@@ -22,88 +28,377 @@ type Callback[K comparable] func(task *Task[K])
 type Task[K comparable] struct {
 	ID       K
 	Name     string
-	state    string // pending, running, finished, canceling, canceled, errored
+	state    string // pending, running, finished, canceling, canceled, errored, paused, waiting
 	status   string
 	progress int
+	// checkpoint is where the task stopped, it's saved so that a paused or
+	// recovered task can continue from it instead of starting over
+	checkpoint string
+	pausing    bool
+	funcDone   bool // the Func succeeded, the children of it are submitted
 
+	// Error should be read by GetErr or GetErrMsg while the task may be running
 	Error error
 
 	Func     Func[K]
 	callback Callback[K]
 
+	// Kind and Args describe what the task does, they are persisted
+	// so that the Func can be rebuilt by the Resumer of the Kind after restart
+	Kind string
+	Args string
+	// Creator is the name of the user who created the task
+	Creator string
+
+	// Group task submits children to do the job, such as copying a folder,
+	// it's finished after all children finished and its progress is the sum of them
+	Group bool
+	// Size is the bytes to transfer, it's used to calculate the progress of groups
+	Size int64
+	// Priority decides which waiting task runs first, the higher the earlier
+	Priority int
+
+	// Retry is the policy to retry the task failed with retryable errors,
+	// the policy of the manager is used if it's nil
+	Retry    *RetryPolicy
+	attempt  int           // the times the task has run since submitted or retried manually
+	attempts []Attempt     // the failed attempts
+	backoff  time.Duration // the delay before the next attempt, it's set when the task will be retried
+
+	// mu protects the fields above which are changed while the task is running,
+	// and the children. it's never held while calling the persist, watch or callback,
+	// and the lock of a parent may be held while locking its children, never the reverse
+	mu       sync.Mutex
+	parent   *Task[K]
+	children []*Task[K]
+
 	Ctx    context.Context
 	cancel context.CancelFunc
+
+	persist   func()
+	persisted time.Time
+	watch     func()
+	watched   time.Time
 }
 
 func (t *Task[K]) SetStatus(status string) {
+	t.mu.Lock()
 	t.status = status
+	t.mu.Unlock()
+	t.persistLater()
 }
 
 func (t *Task[K]) SetProgress(percentage int) {
+	t.mu.Lock()
 	t.progress = percentage
+	t.mu.Unlock()
+	t.persistLater()
+}
+
+// save persist the task and notify the watchers immediately, it's called when the state changed
+func (t *Task[K]) save() {
+	if t.persist != nil {
+		t.mu.Lock()
+		t.persisted = time.Now()
+		t.mu.Unlock()
+		t.persist()
+	}
+	t.notify(true)
+}
+
+// persistLater persist the task if it hasn't been persisted for a while,
+// status and progress change too frequently to be persisted every time
+func (t *Task[K]) persistLater() {
+	t.mu.Lock()
+	due := time.Since(t.persisted) > persistInterval
+	t.mu.Unlock()
+	if due {
+		t.save()
+		return
+	}
+	t.notify(false)
+}
+
+// notify the watchers of the change, the frequent changes are throttled unless force
+func (t *Task[K]) notify(force bool) {
+	if t.watch == nil {
+		return
+	}
+	t.mu.Lock()
+	due := force || time.Since(t.watched) > watchInterval
+	if due {
+		t.watched = time.Now()
+	}
+	t.mu.Unlock()
+	if due {
+		t.watch()
+	}
+}
+
+// SetCheckpoint record where the task is, the format is decided by the task
+func (t *Task[K]) SetCheckpoint(checkpoint string) {
+	t.mu.Lock()
+	t.checkpoint = checkpoint
+	t.mu.Unlock()
+	t.persistLater()
+}
+
+func (t *Task[K]) GetCheckpoint() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.checkpoint
 }
 
-func (t Task[K]) GetProgress() int {
+// Pausing report whether the Ctx is canceled to pause the task,
+// the Func should keep what it has done and return nil
+func (t *Task[K]) Pausing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pausing
+}
+
+// GetProgress return the progress of the task, the progress of a group
+// is calculated by the bytes or the files done by its children
+func (t *Task[K]) GetProgress() int {
+	if t.Group {
+		s := t.Stats()
+		if s.Bytes > 0 {
+			return int(s.DoneBytes * 100 / s.Bytes)
+		}
+		if s.Files > 0 {
+			return s.DoneFiles * 100 / s.Files
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.progress
 }
 
-func (t Task[K]) GetState() string {
+func (t *Task[K]) GetState() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.state
 }
 
-func (t Task[K]) GetStatus() string {
+func (t *Task[K]) GetStatus() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.status
 }
 
-func (t Task[K]) GetErrMsg() string {
+func (t *Task[K]) GetPriority() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Priority
+}
+
+func (t *Task[K]) GetErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Error
+}
+
+func (t *Task[K]) GetErrMsg() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.errMsg()
+}
+
+// errMsg must be called with the lock held
+func (t *Task[K]) errMsg() string {
 	if t.Error == nil {
 		return ""
 	}
 	return t.Error.Error()
 }
 
+// GetAttempts return the history of the failed attempts
+func (t *Task[K]) GetAttempts() []Attempt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Attempt(nil), t.attempts...)
+}
+
 func (t *Task[K]) run() {
+	t.mu.Lock()
 	t.state = RUNNING
+	t.attempt++
+	t.mu.Unlock()
+	t.save()
 	defer func() {
 		if err := recover(); err != nil {
 			log.Errorf("error [%+v] while run task [%s]", err, t.Name)
+			t.mu.Lock()
 			t.Error = errors.Errorf("panic: %+v", err)
 			t.state = ERRORED
+			t.mu.Unlock()
 		}
+		t.save()
 	}()
-	t.Error = t.Func(t)
-	if t.Error != nil {
-		log.Errorf("error [%+v] while run task [%s]", t.Error, t.Name)
+	err := t.Func(t)
+	t.mu.Lock()
+	if t.pausing {
+		// the error is caused by pausing
+		t.Error = nil
+		t.state = PAUSED
+		t.mu.Unlock()
+		return
+	}
+	t.Error = err
+	if err != nil {
+		log.Errorf("error [%+v] while run task [%s]", err, t.Name)
 	}
 	if errors.Is(t.Ctx.Err(), context.Canceled) {
 		t.state = CANCELED
-	} else if t.Error != nil {
-		t.state = ERRORED
-	} else {
-		t.state = SUCCEEDED
-		if t.callback != nil {
-			t.callback(t)
+	} else if err != nil {
+		t.attempts = append(t.attempts, Attempt{Error: err.Error(), Time: time.Now()})
+		if t.shouldRetry() {
+			t.backoff = t.Retry.delay(t.attempt)
+			t.status = fmt.Sprintf("attempt %d/%d failed, retry in %s", t.attempt, t.Retry.MaxAttempts, t.backoff)
+			t.state = PENDING
+		} else {
+			t.state = ERRORED
 		}
+	} else {
+		t.funcDone = true
+		t.mu.Unlock()
+		t.settle(RUNNING)
+		return
 	}
+	t.mu.Unlock()
 }
 
 func (t *Task[K]) retry() {
 	t.run()
 }
 
+// shouldRetry check whether the failed task should be retried automatically,
+// it must be called with the lock held
+func (t *Task[K]) shouldRetry() bool {
+	return t.Retry != nil && t.attempt < t.Retry.MaxAttempts && IsRetryable(t.Error)
+}
+
+// WillRunAgain report whether the manager will run the Func of the task again
+// after it returns err, that's it's pausing or err will be retried automatically.
+// the Func uses it to decide whether to keep what it has done, such as temp files
+func (t *Task[K]) WillRunAgain(err error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pausing {
+		return true
+	}
+	if err == nil || errors.Is(t.Ctx.Err(), context.Canceled) {
+		return false
+	}
+	return t.Retry != nil && t.attempt < t.Retry.MaxAttempts && IsRetryable(err)
+}
+
+// taskKey is the key of the task in its Ctx
+type taskKey struct{}
+
+// WillRunAgain report whether the task running with ctx will run again after it
+// returns err, it's false if ctx is not the Ctx of a task
+func WillRunAgain(ctx context.Context, err error) bool {
+	t, ok := ctx.Value(taskKey{}).(interface{ WillRunAgain(error) bool })
+	return ok && t.WillRunAgain(err)
+}
+
+func (t *Task[K]) GetCreator() string {
+	return t.Creator
+}
+
+// CreatorOf return the Creator of the task running with ctx, it's empty if ctx is not
+// the Ctx of a task, so that the background tasks can act as the user who created them
+func CreatorOf(ctx context.Context) string {
+	if t, ok := ctx.Value(taskKey{}).(interface{ GetCreator() string }); ok {
+		return t.GetCreator()
+	}
+	return ""
+}
+
+// stopped is called when the Ctx of the task is canceled while it's not running
+func (t *Task[K]) stopped() {
+	t.mu.Lock()
+	if t.pausing {
+		t.state = PAUSED
+	} else {
+		t.state = CANCELED
+	}
+	t.mu.Unlock()
+	t.save()
+	t.notifyParent()
+}
+
 func (t *Task[K]) Cancel() {
+	t.mu.Lock()
 	if t.state == SUCCEEDED || t.state == CANCELED {
+		t.mu.Unlock()
 		return
 	}
-	if t.cancel != nil {
-		t.cancel()
+	t.pausing = false
+	cancel := t.cancel
+	t.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	for _, child := range t.GetChildren() {
+		child.Cancel()
+	}
+	t.mu.Lock()
+	switch t.state {
+	case PAUSED:
+		// not running, so it's canceled at once
+		t.state = CANCELED
+		t.mu.Unlock()
+		t.save()
+		t.notifyParent()
+	case PENDING, RUNNING:
+		// maybe can't cancel
+		t.state = CANCELING
+		t.mu.Unlock()
+		t.save()
+	default:
+		// the waiting one is settled after the children canceled,
+		// and the others are finished meanwhile
+		t.mu.Unlock()
+	}
+}
+
+// pause cancel the Ctx of a pending or running task, the state is changed to
+// PAUSED when the Func returns
+func (t *Task[K]) pause() error {
+	t.mu.Lock()
+	if t.state != PENDING && t.state != RUNNING {
+		t.mu.Unlock()
+		return errors.WithStack(ErrTaskNotPausable)
+	}
+	t.pausing = true
+	cancel := t.cancel
+	t.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// resume make a paused task pending with a new Ctx
+func (t *Task[K]) resume() error {
+	t.mu.Lock()
+	if t.state != PAUSED {
+		t.mu.Unlock()
+		return errors.WithStack(ErrTaskNotPaused)
 	}
-	// maybe can't cancel
-	t.state = CANCELING
+	t.pausing = false
+	t.mu.Unlock()
+	WithCancelCtx(t)
+	t.save()
+	return nil
 }
 
 func WithCancelCtx[K comparable](task *Task[K]) *Task[K] {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), taskKey{}, task))
+	task.mu.Lock()
+	defer task.mu.Unlock()
 	task.Ctx = ctx
 	task.cancel = cancel
 	task.state = PENDING
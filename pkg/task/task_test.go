@@ -1,8 +1,12 @@
 package task
 
 import (
+	"context"
+	"fmt"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -24,12 +28,12 @@ func TestTask_Manager(t *testing.T) {
 		t.Fatal("task not found")
 	}
 	time.Sleep(time.Millisecond * 100)
-	if task.state != RUNNING {
-		t.Errorf("task status not running: %s", task.state)
+	if task.GetState() != RUNNING {
+		t.Errorf("task status not running: %s", task.GetState())
 	}
 	time.Sleep(time.Second)
-	if task.state != SUCCEEDED {
-		t.Errorf("task status not finished: %s", task.state)
+	if task.GetState() != SUCCEEDED {
+		t.Errorf("task status not finished: %s", task.GetState())
 	}
 }
 
@@ -56,8 +60,8 @@ func TestTask_Cancel(t *testing.T) {
 	time.Sleep(time.Microsecond * 50)
 	task.Cancel()
 	time.Sleep(time.Millisecond)
-	if task.state != CANCELED {
-		t.Errorf("task status not canceled: %s", task.state)
+	if task.GetState() != CANCELED {
+		t.Errorf("task status not canceled: %s", task.GetState())
 	}
 }
 
@@ -81,15 +85,300 @@ func TestTask_Retry(t *testing.T) {
 		t.Fatal("task not found")
 	}
 	time.Sleep(time.Millisecond)
-	if task.Error == nil {
-		t.Error(task.state)
+	if task.GetErr() == nil {
+		t.Error(task.GetState())
 		t.Fatal("task error is nil, but expected error")
 	} else {
-		t.Logf("task error: %s", task.Error)
+		t.Logf("task error: %s", task.GetErr())
 	}
 	task.retry()
 	time.Sleep(time.Millisecond)
-	if task.Error != nil {
-		t.Errorf("task error: %+v, but expected nil", task.Error)
+	if task.GetErr() != nil {
+		t.Errorf("task error: %+v, but expected nil", task.GetErr())
+	}
+}
+
+func TestManager_RetryUnfinished(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var runs int32
+	release := make(chan struct{})
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "running",
+		Func: func(task *Task[uint64]) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return errors.New("test error")
+		},
+	}))
+	for tm.MustGet(id).GetState() != RUNNING {
+		time.Sleep(time.Millisecond)
+	}
+	// the running Func would be run twice
+	if err := tm.Retry(id); !errors.Is(err, ErrTaskNotRetryable) {
+		t.Errorf("expected not retryable, got %+v", err)
+	}
+	close(release)
+	for tm.MustGet(id).GetState() != ERRORED {
+		time.Sleep(time.Millisecond)
+	}
+	if err := tm.Retry(id); err != nil {
+		t.Fatalf("failed to retry: %+v", err)
+	}
+	for tm.MustGet(id).GetState() != ERRORED {
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("expected run twice, got %d", n)
+	}
+}
+
+type memStore struct {
+	sync.Mutex
+	records map[string]Record
+}
+
+func (s *memStore) Save(r Record) error {
+	s.Lock()
+	defer s.Unlock()
+	s.records[r.ID] = r
+	return nil
+}
+
+func (s *memStore) Delete(id string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memStore) List() ([]Record, error) {
+	s.Lock()
+	defer s.Unlock()
+	var records []Record
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (s *memStore) has(id string) bool {
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.records[id]
+	return ok
+}
+
+func (s *memStore) get(id string) Record {
+	s.Lock()
+	defer s.Unlock()
+	return s.records[id]
+}
+
+func TestTask_Recover(t *testing.T) {
+	store := &memStore{records: map[string]Record{}}
+	newManager := func() *Manager[uint64] {
+		tm := NewTaskManager[uint64](3, func(id *uint64) {
+			atomic.AddUint64(id, 1)
+		})
+		tm.SetStore(store)
+		return tm
+	}
+	tm := newManager()
+	done := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "done",
+		Func: func(task *Task[uint64]) error {
+			return nil
+		},
+	}))
+	block := make(chan struct{})
+	undone := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "undone",
+		Kind: "test",
+		Args: "args",
+		Func: func(task *Task[uint64]) error {
+			<-block
+			return nil
+		},
+	}))
+	time.Sleep(time.Millisecond * 100)
+	if r := store.get("2"); r.State != RUNNING || r.Args != "args" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+
+	// restart
+	tm = newManager()
+	resumed := make(chan string, 1)
+	tm.RegisterResumer("test", func(task *Task[uint64]) error {
+		task.Func = func(task *Task[uint64]) error {
+			resumed <- task.Args
+			return nil
+		}
+		return nil
+	})
+	if err := tm.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if task, ok := tm.Get(done); !ok || task.GetState() != SUCCEEDED {
+		t.Errorf("finished task should be kept")
+	}
+	select {
+	case args := <-resumed:
+		if args != "args" {
+			t.Errorf("unexpected args: %s", args)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("task not resumed")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if r := store.get("2"); r.State != SUCCEEDED {
+		t.Errorf("unexpected state of resumed task: %s", r.State)
+	}
+	if id := tm.Submit(WithCancelCtx(&Task[uint64]{Name: "new", Func: func(task *Task[uint64]) error { return nil }})); id == done || id == undone {
+		t.Errorf("id %d of recovered task is reused", id)
+	}
+	tm.Remove(done)
+	if store.has("1") {
+		t.Errorf("removed task should be deleted from store")
+	}
+	close(block)
+}
+
+// childFirstStore list the children before their parents
+type childFirstStore struct {
+	memStore
+}
+
+func (s *childFirstStore) List() ([]Record, error) {
+	records, err := s.memStore.List()
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ParentID != "" && records[j].ParentID == ""
+	})
+	return records, err
+}
+
+func TestTask_RecoverChildFirst(t *testing.T) {
+	store := &childFirstStore{memStore{records: map[string]Record{
+		"1": {ID: "1", Name: "parent", Kind: "test", State: WAITING, Group: true},
+		"2": {ID: "2", Name: "child", ParentID: "1", State: SUCCEEDED},
+		"3": {ID: "3", Name: "child", ParentID: "1", State: SUCCEEDED},
+	}}}
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	tm.SetStore(store)
+	tm.RegisterResumer("test", func(task *Task[uint64]) error {
+		task.Func = func(task *Task[uint64]) error {
+			return nil
+		}
+		return nil
+	})
+	if err := tm.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	parent := tm.MustGet(1)
+	if n := len(parent.GetChildren()); n != 2 {
+		t.Fatalf("expected 2 children linked, got %d", n)
+	}
+	if parent.GetState() != SUCCEEDED {
+		t.Errorf("expected the parent settled by its children, got %s", parent.GetState())
+	}
+}
+
+func TestTask_Pause(t *testing.T) {
+	tm := NewTaskManager[uint64](1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var count int64
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "test",
+		Func: func(task *Task[uint64]) error {
+			var n int64
+			fmt.Sscan(task.GetCheckpoint(), &n)
+			for ; n < 10; n++ {
+				if utils.IsCanceled(task.Ctx) {
+					return task.Ctx.Err()
+				}
+				atomic.AddInt64(&count, 1)
+				task.SetCheckpoint(fmt.Sprint(n + 1))
+				time.Sleep(time.Millisecond * 20)
+			}
+			return nil
+		},
+	}))
+	task, _ := tm.Get(id)
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if task.GetState() != PAUSED || task.GetErr() != nil {
+		t.Fatalf("task should be paused: %s %v", task.GetState(), task.GetErr())
+	}
+	// the worker is released
+	other := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "other",
+		Func: func(task *Task[uint64]) error {
+			return nil
+		},
+	}))
+	time.Sleep(time.Millisecond * 50)
+	if tm.MustGet(other).GetState() != SUCCEEDED {
+		t.Errorf("worker of paused task is not released")
+	}
+	if err := tm.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 500)
+	if task.GetState() != SUCCEEDED {
+		t.Errorf("task should be succeeded: %s", task.GetState())
+	}
+	if atomic.LoadInt64(&count) != 10 {
+		t.Errorf("task should continue from checkpoint, but run %d times", count)
+	}
+	if err := tm.Resume(id); !errors.Is(err, ErrTaskNotPaused) {
+		t.Errorf("expect not paused error, got %v", err)
+	}
+}
+
+func TestTask_SubmitConcurrently(t *testing.T) {
+	tm := NewTaskManager[uint64](3, func(id *uint64) {
+		*id++
+	})
+	var wg sync.WaitGroup
+	ids := make([]uint64, 100)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = tm.Submit(WithCancelCtx(&Task[uint64]{
+				Name: "test",
+				Func: func(task *Task[uint64]) error {
+					return nil
+				},
+			}))
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("id %d is allocated more than once", id)
+		}
+		seen[id] = true
+	}
+	if n := len(tm.GetAll()); n != len(ids) {
+		t.Errorf("expected %d tasks, but got %d", len(ids), n)
+	}
+}
+
+func TestTask_CreatorOf(t *testing.T) {
+	tsk := WithCancelCtx(&Task[uint64]{Name: "task", Creator: "alice"})
+	if creator := CreatorOf(tsk.Ctx); creator != "alice" {
+		t.Errorf("expected the creator of the task, got %q", creator)
+	}
+	if creator := CreatorOf(context.Background()); creator != "" {
+		t.Errorf("expected no creator, got %q", creator)
 	}
 }
@@ -0,0 +1,98 @@
+package task
+
+import (
+	"sync"
+)
+
+// workers limit the number of running tasks of a manager, the number can be
+// changed at runtime. the waiting task with higher priority gets the worker
+// first, and the earlier one gets it if they have the same priority
+type workers[K comparable] struct {
+	mu      sync.Mutex
+	max     int
+	running int
+	seq     uint64
+	queue   []*ticket[K]
+}
+
+type ticket[K comparable] struct {
+	task  *Task[K]
+	seq   uint64
+	start chan struct{}
+}
+
+// wait queue the task for a worker, the start of the ticket is closed once it gets one
+func (w *workers[K]) wait(task *Task[K]) *ticket[K] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seq++
+	t := &ticket[K]{task: task, seq: w.seq, start: make(chan struct{})}
+	w.queue = append(w.queue, t)
+	w.dispatch()
+	return t
+}
+
+// leave remove the ticket from the queue, it returns false if the ticket has got a worker
+func (w *workers[K]) leave(t *ticket[K]) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.queue {
+		if w.queue[i] == t {
+			w.queue = append(w.queue[:i], w.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// release return the worker and hand it to the next waiting task
+func (w *workers[K]) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running--
+	w.dispatch()
+}
+
+// resize change the max number of workers, the running tasks exceeding it
+// are not stopped, but no task is started until the number drops below it
+func (w *workers[K]) resize(max int) {
+	if max < 1 {
+		max = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.max = max
+	w.dispatch()
+}
+
+func (w *workers[K]) size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.max
+}
+
+func (w *workers[K]) setPriority(task *Task[K], priority int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// the priority is read by the workers while dispatching and by the task while persisting
+	task.mu.Lock()
+	defer task.mu.Unlock()
+	task.Priority = priority
+}
+
+// dispatch must be called with the lock held
+func (w *workers[K]) dispatch() {
+	for w.running < w.max && len(w.queue) > 0 {
+		next := 0
+		for i, t := range w.queue {
+			if t.task.Priority > w.queue[next].task.Priority ||
+				(t.task.Priority == w.queue[next].task.Priority && t.seq < w.queue[next].seq) {
+				next = i
+			}
+		}
+		t := w.queue[next]
+		w.queue = append(w.queue[:next], w.queue[next+1:]...)
+		w.running++
+		close(t.start)
+	}
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTask_Priority(t *testing.T) {
+	tm := NewTaskManager[uint64](1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	block := make(chan struct{})
+	tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "block",
+		Func: func(task *Task[uint64]) error {
+			<-block
+			return nil
+		},
+	}))
+	time.Sleep(time.Millisecond * 50)
+	var mu sync.Mutex
+	var order []string
+	submit := func(name string, priority int) uint64 {
+		return tm.Submit(WithCancelCtx(&Task[uint64]{
+			Name:     name,
+			Priority: priority,
+			Func: func(task *Task[uint64]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, task.Name)
+				return nil
+			},
+		}))
+	}
+	submit("low", 0)
+	id := submit("raised", 0)
+	submit("high", 1)
+	if err := tm.SetPriority(id, 2); err != nil {
+		t.Fatalf("failed set priority: %+v", err)
+	}
+	close(block)
+	time.Sleep(time.Millisecond * 100)
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"raised", "high", "low"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected order %v, but got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected order %v, but got %v", expected, order)
+		}
+	}
+}
+
+func TestTask_SetWorkers(t *testing.T) {
+	tm := NewTaskManager[uint64](1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	block := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		tm.Submit(WithCancelCtx(&Task[uint64]{
+			Name: "test",
+			Func: func(task *Task[uint64]) error {
+				<-block
+				return nil
+			},
+		}))
+	}
+	time.Sleep(time.Millisecond * 50)
+	running := func() int {
+		return len(tm.GetByStates(RUNNING))
+	}
+	if n := running(); n != 1 {
+		t.Fatalf("expected 1 running task, but got %d", n)
+	}
+	tm.SetWorkers(3)
+	time.Sleep(time.Millisecond * 50)
+	if n := running(); n != 3 {
+		t.Fatalf("expected 3 running tasks after resize, but got %d", n)
+	}
+	close(block)
+	time.Sleep(time.Millisecond * 50)
+	if n := len(tm.GetByStates(SUCCEEDED)); n != 3 {
+		t.Errorf("expected 3 succeeded tasks, but got %d", n)
+	}
+}
@@ -0,0 +1,131 @@
+package transmission
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// stub emulate the part of Transmission RPC used by the client
+type stub struct {
+	mu       sync.Mutex
+	session  string
+	torrents map[string]*Torrent
+}
+
+func newStub(t *testing.T) (*stub, *httptest.Server) {
+	s := &stub{session: "session1", torrents: map[string]*Torrent{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get(sessionHeader) != s.session {
+		w.Header().Set(sessionHeader, s.session)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	var req struct {
+		Method    string `json:"method"`
+		Arguments struct {
+			Ids        []string `json:"ids"`
+			Filename   string   `json:"filename"`
+			DownDir    string   `json:"download-dir"`
+			Paused     bool     `json:"paused"`
+			DeleteData bool     `json:"delete-local-data"`
+		} `json:"arguments"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	args := req.Arguments
+	var res interface{} = struct{}{}
+	switch req.Method {
+	case "session-get":
+		res = map[string]interface{}{"version": "3.00"}
+	case "torrent-add":
+		hash := "hash-" + args.Filename
+		if t, ok := s.torrents[hash]; ok {
+			res = map[string]interface{}{"torrent-duplicate": t}
+			break
+		}
+		t := &Torrent{HashString: hash, Name: "file.bin", Status: StatusDownload, DownloadDir: args.DownDir,
+			SizeWhenDone: 10, LeftUntilDone: 10, Files: []File{{Name: "file.bin", Length: 10}}}
+		if args.Paused {
+			t.Status = StatusStopped
+		}
+		s.torrents[hash] = t
+		res = map[string]interface{}{"torrent-added": t}
+	case "torrent-get":
+		var torrents []*Torrent
+		for _, id := range args.Ids {
+			if t, ok := s.torrents[id]; ok {
+				torrents = append(torrents, t)
+			}
+		}
+		res = map[string]interface{}{"torrents": torrents}
+	case "torrent-start", "torrent-stop":
+		for _, id := range args.Ids {
+			if t, ok := s.torrents[id]; ok {
+				t.Status = map[bool]int{true: StatusDownload, false: StatusStopped}[req.Method == "torrent-start"]
+			}
+		}
+	case "torrent-remove":
+		for _, id := range args.Ids {
+			delete(s.torrents, id)
+		}
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": "method name not recognized"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": "success", "arguments": res})
+}
+
+func TestClient(t *testing.T) {
+	s, srv := newStub(t)
+	c, err := New(strings.Replace(srv.URL, "http://", "http://user:pass@", 1) + "/transmission/rpc")
+	if err != nil {
+		t.Fatalf("failed connect: %+v", err)
+	}
+	if version, err := c.Version(); err != nil || version != "3.00" {
+		t.Errorf("unexpected version: %s %+v", version, err)
+	}
+	hash, err := c.Add("magnet:?xt=urn:btih:abc", "/tmp/dir", true)
+	if err != nil {
+		t.Fatalf("failed add: %+v", err)
+	}
+	// the same torrent is not added again
+	if dup, err := c.Add("magnet:?xt=urn:btih:abc", "/tmp/dir", true); err != nil || dup != hash {
+		t.Errorf("expected duplicate %s, but got %s %+v", hash, dup, err)
+	}
+	// the session is renewed
+	s.mu.Lock()
+	s.session = "session2"
+	s.mu.Unlock()
+	if err := c.Start(hash); err != nil {
+		t.Fatalf("failed start: %+v", err)
+	}
+	tr, err := c.Get(hash)
+	if err != nil {
+		t.Fatalf("failed get: %+v", err)
+	}
+	if tr.Status != StatusDownload || tr.DownloadDir != "/tmp/dir" || len(tr.Files) != 1 {
+		t.Errorf("unexpected torrent: %+v", tr)
+	}
+	if err := c.Remove(hash, false); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	if _, err := c.Get(hash); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, but got %v", err)
+	}
+}
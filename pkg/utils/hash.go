@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HashType is the algorithm of a hash, the standard ones can be computed,
+// and the others are specific to the storage backends
+type HashType string
+
+const (
+	MD5    HashType = "md5"
+	SHA1   HashType = "sha1"
+	SHA256 HashType = "sha256"
+)
+
+var StandardHashTypes = []HashType{MD5, SHA1, SHA256}
+
+// New return the hash of the type, nil if it's not a standard type
+func (t HashType) New() hash.Hash {
+	switch t {
+	case MD5:
+		return md5.New()
+	case SHA1:
+		return sha1.New()
+	case SHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// HashInfo is the hex encoded hashes of a file by their types
+type HashInfo map[HashType]string
+
+// Equal compare the hashes of the types both have, compared is false if there is no such type
+func (hi HashInfo) Equal(other HashInfo) (equal bool, compared bool) {
+	for t, h := range hi {
+		if o, ok := other[t]; ok && h != "" && o != "" {
+			if !strings.EqualFold(h, o) {
+				return false, true
+			}
+			compared = true
+		}
+	}
+	return true, compared
+}
+
+// String format the hashes as "type:hash" separated by space, ordered by type
+func (hi HashInfo) String() string {
+	res := make([]string, 0, len(hi))
+	for t, h := range hi {
+		res = append(res, string(t)+":"+h)
+	}
+	sort.Strings(res)
+	return strings.Join(res, " ")
+}
+
+// MultiHasher compute the hashes of the standard types while written
+type MultiHasher struct {
+	hashes map[HashType]hash.Hash
+	w      io.Writer
+}
+
+func NewMultiHasher(types ...HashType) *MultiHasher {
+	m := &MultiHasher{hashes: make(map[HashType]hash.Hash)}
+	var writers []io.Writer
+	for _, t := range types {
+		if h := t.New(); h != nil {
+			m.hashes[t] = h
+			writers = append(writers, h)
+		}
+	}
+	m.w = io.MultiWriter(writers...)
+	return m
+}
+
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	return m.w.Write(p)
+}
+
+func (m *MultiHasher) Sum() HashInfo {
+	hi := make(HashInfo, len(m.hashes))
+	for t, h := range m.hashes {
+		hi[t] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hi
+}
+
+// HashFile compute the hashes of the file in one pass
+func HashFile(path string, types ...HashType) (HashInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	m := NewMultiHasher(types...)
+	if _, err := io.Copy(m, f); err != nil {
+		return nil, errors.Wrapf(err, "failed read %s", path)
+	}
+	return m.Sum(), nil
+}
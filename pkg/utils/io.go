@@ -33,3 +33,9 @@ func CopyWithCtx(ctx context.Context, out io.Writer, in io.Reader) error {
 	}))
 	return err
 }
+
+// ReadCloser combine a reader and a closer, such as a limited reader of a file
+type ReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -29,6 +29,15 @@ func PathEqual(path1, path2 string) bool {
 	return StandardizePath(path1) == StandardizePath(path2)
 }
 
+// IsSubPath judge sub is the path itself or in the path
+func IsSubPath(path string, sub string) bool {
+	path, sub = StandardizePath(path), StandardizePath(sub)
+	if path == "/" || path == sub {
+		return true
+	}
+	return strings.HasPrefix(sub, path+"/")
+}
+
 func Ext(path string) string {
 	ext := stdpath.Ext(path)
 	if strings.HasPrefix(ext, ".") {
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestIsSubPath(t *testing.T) {
+	tests := []struct {
+		path string
+		sub  string
+		want bool
+	}{
+		{"/", "/a", true},
+		{"/a", "/a", true},
+		{"/a/", "/a", true},
+		{"/a", "/a/b", true},
+		{"/a", "/ab", false},
+		{"/a/b", "/a", false},
+		{"/a", "/b/a", false},
+	}
+	for _, tt := range tests {
+		if got := IsSubPath(tt.path, tt.sub); got != tt.want {
+			t.Errorf("IsSubPath(%q, %q) = %v, want %v", tt.path, tt.sub, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Package zip_crypto reads the encrypted files of zip, which is not supported by archive/zip.
+// both the traditional PKWARE encryption (ZipCrypto) and the WinZip AES encryption are supported.
+package zip_crypto
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	ErrPassword       = errors.New("zip: invalid password")
+	ErrPasswordNeeded = errors.New("zip: password is needed")
+	ErrAuthentication = errors.New("zip: authentication failed")
+	ErrAlgorithm      = errors.New("zip: unsupported encryption")
+)
+
+const (
+	methodAES    = 99
+	aesExtraID   = 0x9901
+	aesIteration = 1000
+	aesMacSize   = 10
+	flagEncrypt  = 0x1
+	flagDataDesc = 0x8
+)
+
+// IsEncrypted judge the file is encrypted
+func IsEncrypted(f *zip.File) bool {
+	return f.Flags&flagEncrypt != 0
+}
+
+// Open open the file in zip, the file is decrypted with password if it's encrypted
+func Open(f *zip.File, password string) (io.ReadCloser, error) {
+	if !IsEncrypted(f) {
+		return f.Open()
+	}
+	if password == "" {
+		return nil, errors.WithStack(ErrPasswordNeeded)
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	method := f.Method
+	checkCRC, isAES := true, method == methodAES
+	var r io.Reader
+	if isAES {
+		extra, ok := aesExtra(f.Extra)
+		if !ok {
+			return nil, errors.WithStack(ErrAlgorithm)
+		}
+		method = extra.method
+		// AE-2 doesn't store crc, the data is authenticated by hmac
+		checkCRC = extra.version == 1
+		r, err = newAESReader(raw, int64(f.CompressedSize64), extra.strength, password)
+	} else {
+		verifier := byte(f.CRC32 >> 24)
+		if f.Flags&flagDataDesc != 0 {
+			verifier = byte(f.ModifiedTime >> 8)
+		}
+		r, err = newZipCryptoReader(raw, verifier, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = ioutil.NopCloser(r)
+	case zip.Deflate:
+		rc = flate.NewReader(r)
+	default:
+		return nil, errors.WithStack(zip.ErrAlgorithm)
+	}
+	if isAES {
+		// the decompressor may stop before the end of the data, so the hmac is never checked
+		rc = &drainReader{ReadCloser: rc, src: r}
+	}
+	if checkCRC {
+		rc = &checksumReader{rc: rc, hash: crc32.NewIEEE(), want: f.CRC32}
+	}
+	return rc, nil
+}
+
+type aesExtraField struct {
+	version  uint16
+	strength byte
+	method   uint16
+}
+
+func aesExtra(extra []byte) (aesExtraField, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == aesExtraID && size >= 7 {
+			return aesExtraField{
+				version:  binary.LittleEndian.Uint16(extra),
+				strength: extra[4],
+				method:   binary.LittleEndian.Uint16(extra[5:]),
+			}, true
+		}
+		extra = extra[size:]
+	}
+	return aesExtraField{}, false
+}
+
+// ZipCrypto
+
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decryptByte(b byte) byte {
+	t := k[2] | 2
+	b ^= byte((t * (t ^ 1)) >> 8)
+	k.update(b)
+	return b
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func newZipCryptoReader(r io.Reader, verifier byte, password string) (io.Reader, error) {
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i := range header {
+		header[i] = keys.decryptByte(header[i])
+	}
+	if header[11] != verifier {
+		return nil, errors.WithStack(ErrPassword)
+	}
+	return &zipCryptoReader{r: r, keys: keys}, nil
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] = z.keys.decryptByte(p[i])
+	}
+	return n, err
+}
+
+// WinZip AES
+
+// aesKeyLength return the key length of the strength, 1: AES-128, 2: AES-192, 3: AES-256
+func aesKeyLength(strength byte) int {
+	switch strength {
+	case 1:
+		return 16
+	case 2:
+		return 24
+	case 3:
+		return 32
+	}
+	return 0
+}
+
+type aesReader struct {
+	r       io.Reader
+	stream  cipher.Stream
+	mac     hash.Hash
+	footer  io.Reader
+	checked bool
+}
+
+func newAESReader(r io.Reader, size int64, strength byte, password string) (io.Reader, error) {
+	keyLen := aesKeyLength(strength)
+	if keyLen == 0 {
+		return nil, errors.WithStack(ErrAlgorithm)
+	}
+	saltLen := keyLen / 2
+	dataLen := size - int64(saltLen) - 2 - aesMacSize
+	if dataLen < 0 {
+		return nil, errors.WithStack(zip.ErrFormat)
+	}
+	header := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	key := pbkdf2.Key([]byte(password), header[:saltLen], aesIteration, 2*keyLen+2, sha1.New)
+	if subtle.ConstantTimeCompare(key[2*keyLen:], header[saltLen:]) != 1 {
+		return nil, errors.WithStack(ErrPassword)
+	}
+	block, err := aes.NewCipher(key[:keyLen])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &aesReader{
+		r:      io.LimitReader(r, dataLen),
+		stream: newWinZipCTR(block),
+		mac:    hmac.New(sha1.New, key[keyLen:2*keyLen]),
+		footer: r,
+	}, nil
+}
+
+func (a *aesReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.mac.Write(p[:n])
+		a.stream.XORKeyStream(p[:n], p[:n])
+	}
+	if err == io.EOF && !a.checked {
+		a.checked = true
+		code := make([]byte, aesMacSize)
+		if _, err := io.ReadFull(a.footer, code); err != nil {
+			return n, errors.WithStack(err)
+		}
+		if !hmac.Equal(code, a.mac.Sum(nil)[:aesMacSize]) {
+			return n, errors.WithStack(ErrAuthentication)
+		}
+	}
+	return n, err
+}
+
+// winZipCTR is the counter mode of WinZip AES, which counter is little endian and starts from 1,
+// while cipher.NewCTR use big endian counter
+type winZipCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	buf     [aes.BlockSize]byte
+	used    int
+}
+
+func newWinZipCTR(block cipher.Block) cipher.Stream {
+	return &winZipCTR{block: block, used: aes.BlockSize}
+}
+
+func (c *winZipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.used == aes.BlockSize {
+			for j := range c.counter {
+				c.counter[j]++
+				if c.counter[j] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.buf[:], c.counter[:])
+			c.used = 0
+		}
+		dst[i] = src[i] ^ c.buf[c.used]
+		c.used++
+	}
+}
+
+type checksumReader struct {
+	rc   io.ReadCloser
+	hash hash.Hash32
+	want uint32
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.hash.Write(p[:n])
+	if err == io.EOF && c.hash.Sum32() != c.want {
+		return n, errors.WithStack(zip.ErrChecksum)
+	}
+	return n, err
+}
+
+func (c *checksumReader) Close() error {
+	return c.rc.Close()
+}
+
+// drainReader read the rest of src after the end of the ReadCloser
+type drainReader struct {
+	io.ReadCloser
+	src io.Reader
+}
+
+func (d *drainReader) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	if err == io.EOF {
+		if _, e := io.Copy(ioutil.Discard, d.src); e != nil {
+			return n, e
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,142 @@
+package zip_crypto
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// created by `zip -P secret z.zip a.txt b.txt`, a.txt is stored and b.txt is deflated
+const zipCryptoArchive = "UEsDBAoACQAAAHlIUl2ulpG2HAAAABAAAAAFABwAYS50eHRVVAkAA3WL1Gp1i9RqdXgLAAEEAAAAAAQAAAAAgslU41D1c2mGMsjLTnLVLrCAWNEna6kbk2IKslBLBwiulpG2HAAAABAAAABQSwMEFAAJAAgAeUhSXecX7pggAAAAuAsAAAUAHABiLnR4dFVUCQADdYvUanWL1Gp1eAsAAQQAAAAABAAAAAD3HAnz1d5oVnUpcwXQd5fj+KfRHd42yNdgzqyfHW2VN1BLBwjnF+6YIAAAALgLAABQSwECHgMKAAkAAAB5SFJdrpaRthwAAAAQAAAABQAYAAAAAAABAAAApIEAAAAAYS50eHRVVAUAA3WL1Gp1eAsAAQQAAAAABAAAAABQSwECHgMUAAkACAB5SFJd5xfumCAAAAC4CwAABQAYAAAAAAABAAAApIFrAAAAYi50eHRVVAUAA3WL1Gp1eAsAAQQAAAAABAAAAABQSwUGAAAAAAIAAgCWAAAA2gAAAAAA"
+
+func readAll(f *zip.File, password string) ([]byte, error) {
+	rc, err := Open(f, password)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func TestZipCrypto(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString(zipCryptoArchive)
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{
+		"a.txt": []byte("hello zipcrypto\n"),
+		"b.txt": bytes.Repeat([]byte("x"), 3000),
+	}
+	for _, f := range r.File {
+		if !IsEncrypted(f) {
+			t.Errorf("%s should be encrypted", f.Name)
+		}
+		content, err := readAll(f, "secret")
+		if err != nil {
+			t.Fatalf("failed to read %s: %+v", f.Name, err)
+		}
+		if !bytes.Equal(content, expected[f.Name]) {
+			t.Errorf("unexpected content of %s: %q", f.Name, content)
+		}
+		if _, err := readAll(f, ""); !errors.Is(err, ErrPasswordNeeded) {
+			t.Errorf("expect password needed error, got %v", err)
+		}
+		// the check byte of a wrong password may match by chance, then the crc is wrong
+		if _, err := readAll(f, "wrong"); err == nil {
+			t.Errorf("expect error with wrong password")
+		}
+	}
+}
+
+// createAES create a zip with a deflated file encrypted by WinZip AES-256
+func createAES(t *testing.T, name string, content []byte, password string, version uint16) []byte {
+	compressed := new(bytes.Buffer)
+	fw, _ := flate.NewWriter(compressed, flate.DefaultCompression)
+	_, _ = fw.Write(content)
+	_ = fw.Close()
+
+	salt := bytes.Repeat([]byte{7}, 16)
+	key := pbkdf2.Key([]byte(password), salt, aesIteration, 2*32+2, sha1.New)
+	block, _ := aes.NewCipher(key[:32])
+	encrypted := make([]byte, compressed.Len())
+	newWinZipCTR(block).XORKeyStream(encrypted, compressed.Bytes())
+	mac := hmac.New(sha1.New, key[32:64])
+	mac.Write(encrypted)
+
+	data := new(bytes.Buffer)
+	data.Write(salt)
+	data.Write(key[64:])
+	data.Write(encrypted)
+	data.Write(mac.Sum(nil)[:aesMacSize])
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra, aesExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], version)
+	copy(extra[6:], "AE")
+	extra[8] = 3
+	binary.LittleEndian.PutUint16(extra[9:], zip.Deflate)
+
+	hdr := &zip.FileHeader{
+		Name:               name,
+		Method:             methodAES,
+		Flags:              flagEncrypt,
+		Extra:              extra,
+		CompressedSize64:   uint64(data.Len()),
+		UncompressedSize64: uint64(len(content)),
+	}
+	if version == 1 {
+		hdr.CRC32 = crc32.ChecksumIEEE(content)
+	}
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	fw2, err := w.CreateRaw(hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw2.Write(data.Bytes())
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAES(t *testing.T) {
+	content := bytes.Repeat([]byte("winzip aes "), 500)
+	for _, version := range []uint16{1, 2} {
+		data := createAES(t, "aes.txt", content, "secret", version)
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := r.File[0]
+		got, err := readAll(f, "secret")
+		if err != nil {
+			t.Fatalf("failed to read AE-%d: %+v", version, err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("unexpected content of AE-%d", version)
+		}
+		if _, err := readAll(f, "wrong"); !errors.Is(err, ErrPassword) {
+			t.Errorf("expect password error of AE-%d, got %v", version, err)
+		}
+		// tamper the encrypted data
+		data[bytes.Index(data, []byte("aes.txt"))+len("aes.txt")+11+20] ^= 1
+		r, _ = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if _, err := readAll(r.File[0], "secret"); err == nil {
+			t.Errorf("expect error of tampered AE-%d", version)
+		}
+	}
+}
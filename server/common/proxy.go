@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/pkg/errors"
@@ -17,6 +18,19 @@ import (
 var HttpClient = &http.Client{}
 
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
+	// read data by ranges, ServeContent handles the Range and If-Range headers
+	if link.RangeReader != nil {
+		if link.Data != nil {
+			_ = link.Data.Close()
+		}
+		rs := &rangeReadSeeker{ctx: r.Context(), rr: link.RangeReader, size: file.GetSize()}
+		defer func() {
+			_ = rs.Close()
+		}()
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, file.GetName(), url.QueryEscape(file.GetName())))
+		http.ServeContent(w, r, file.GetName(), file.ModTime(), rs)
+		return nil
+	}
 	// read data with native
 	var err error
 	if link.Data != nil {
@@ -97,3 +111,55 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 		return nil
 	}
 }
+
+// rangeReadSeeker implement io.ReadSeeker by the RangeReader, the range is opened
+// from the offset at the first read after seeking
+type rangeReadSeeker struct {
+	ctx    context.Context
+	rr     model.RangeReader
+	size   int64
+	offset int64
+	rc     io.ReadCloser
+}
+
+func (r *rangeReadSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.rc == nil {
+		rc, err := r.rr.RangeRead(r.ctx, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.rc = rc
+	}
+	n, err := r.rc.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if offset != r.offset {
+		_ = r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *rangeReadSeeker) Close() error {
+	if r.rc == nil {
+		return nil
+	}
+	err := r.rc.Close()
+	r.rc = nil
+	return err
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func rangeLink(content []byte, opened *int) *model.Link {
+	return &model.Link{RangeReader: model.RangeReaderFunc(func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		*opened++
+		r := bytes.NewReader(content[offset:])
+		if length < 0 {
+			return ioutil.NopCloser(r), nil
+		}
+		return ioutil.NopCloser(io.LimitReader(r, length)), nil
+	})}
+}
+
+func TestProxy_RangeReader(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	modified := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	file := &model.Object{Name: "a.bin", Size: int64(len(content)), Modified: modified}
+	serve := func(header http.Header) (*httptest.ResponseRecorder, int) {
+		opened := 0
+		r := httptest.NewRequest(http.MethodGet, "/d/a.bin", nil)
+		r.Header = header
+		w := httptest.NewRecorder()
+		if err := Proxy(w, r, rangeLink(content, &opened), file); err != nil {
+			t.Fatalf("failed proxy: %+v", err)
+		}
+		return w, opened
+	}
+
+	w, _ := serve(http.Header{})
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Errorf("unexpected full response: %d %s", w.Code, w.Body.String())
+	}
+
+	w, opened := serve(http.Header{"Range": []string{"bytes=5-9"}})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "56789" || w.Header().Get("Content-Range") != "bytes 5-9/20" {
+		t.Errorf("unexpected range response: %d %s %s", w.Code, w.Body.String(), w.Header().Get("Content-Range"))
+	}
+	if opened != 1 {
+		t.Errorf("expected the range opened once, but opened %d times", opened)
+	}
+
+	w, _ = serve(http.Header{"Range": []string{"bytes=0-1,-3"}})
+	_, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if w.Code != http.StatusPartialContent || err != nil {
+		t.Fatalf("unexpected multi-range response: %d %+v", w.Code, err)
+	}
+	var parts []string
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(p)
+		parts = append(parts, string(data))
+	}
+	if len(parts) != 2 || parts[0] != "01" || parts[1] != "hij" {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+
+	// the file is modified since If-Range, so the whole file is returned
+	w, _ = serve(http.Header{
+		"Range":    []string{"bytes=5-9"},
+		"If-Range": []string{modified.Add(-time.Hour).Format(http.TimeFormat)},
+	})
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Errorf("unexpected response of stale If-Range: %d", w.Code)
+	}
+	w, _ = serve(http.Header{
+		"Range":    []string{"bytes=5-9"},
+		"If-Range": []string{modified.Format(http.TimeFormat)},
+	})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "56789" {
+		t.Errorf("unexpected response of fresh If-Range: %d", w.Code)
+	}
+
+	w, _ = serve(http.Header{"Range": []string{"bytes=30-"}})
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("expected 416, but got %d", w.Code)
+	}
+}
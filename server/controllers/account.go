@@ -68,3 +68,17 @@ func DeleteAccount(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+func ReloadAccount(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := operations.ReloadAccountById(c, uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -37,9 +37,69 @@ func SetAria2(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+type SetQbittorrentReq struct {
+	Url string `json:"url" form:"url"`
+}
+
+func SetQbittorrent(c *gin.Context) {
+	var req SetQbittorrentReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// keep the help of the item
+	item, err := db.GetSettingItemByKey(conf.QbittorrentUrl)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	item.Value = req.Url
+	if err := db.SaveSettingItem(*item); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err := aria2.InitQbittorrentClient(); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type SetTransmissionReq struct {
+	Url string `json:"url" form:"url"`
+}
+
+func SetTransmission(c *gin.Context) {
+	var req SetTransmissionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// keep the help of the item
+	item, err := db.GetSettingItemByKey(conf.TransmissionUrl)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	item.Value = req.Url
+	if err := db.SaveSettingItem(*item); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err := aria2.InitTransmissionClient(); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
 type AddAria2Req struct {
 	Urls []string `json:"urls"`
 	Path string   `json:"path"`
+	// Tool is the offline download tool: aria2, http, qbittorrent or transmission,
+	// it's aria2 if it's ready, otherwise http
+	Tool     string `json:"tool"`
+	Checksum string `json:"checksum"`
 }
 
 func AddAria2(c *gin.Context) {
@@ -48,18 +108,23 @@ func AddAria2(c *gin.Context) {
 		common.ErrorStrResp(c, "permission denied", 403)
 		return
 	}
-	if !aria2.IsAria2Ready() {
-		common.ErrorStrResp(c, "aria2 not ready", 500)
-		return
-	}
 	var req AddAria2Req
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	tool, err := aria2.GetTool(req.Tool)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !tool.IsReady() {
+		common.ErrorStrResp(c, tool.Name()+" not ready", 500)
+		return
+	}
 	req.Path = stdpath.Join(user.BasePath, req.Path)
 	for _, url := range req.Urls {
-		err := aria2.AddURI(c, url, req.Path)
+		err := aria2.AddURI(c, url, req.Path, aria2.DownOptions{Tool: tool.Name(), Checksum: req.Checksum})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/sign"
+	"net/http"
 	stdpath "path"
 	"strings"
 
@@ -28,7 +31,7 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	} else {
-		link, _, err := fs.Link(c, rawPath, model.LinkArgs{
+		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			IP:     c.ClientIP(),
 			Header: c.Request.Header,
 		})
@@ -36,6 +39,14 @@ func Down(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
+		// such as an entry in an archive, which can only be proxied
+		if link.URL == "" {
+			err = common.Proxy(limitDown(c, account), c.Request, link, file)
+			if err != nil {
+				common.ErrorResp(c, err, 500, true)
+			}
+			return
+		}
 		c.Redirect(302, link.URL)
 	}
 }
@@ -65,7 +76,7 @@ func Proxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		err = common.Proxy(c.Writer, c.Request, link, file)
+		err = common.Proxy(limitDown(c, account), c.Request, link, file)
 		if err != nil {
 			common.ErrorResp(c, err, 500, true)
 			return
@@ -76,6 +87,15 @@ func Proxy(c *gin.Context) {
 	}
 }
 
+// limitDown limit the bandwidth of the proxied download, the anonymous user is limited as guest
+func limitDown(c *gin.Context, account driver.Driver) http.ResponseWriter {
+	user := bandwidth.UserOf(c)
+	if user == nil {
+		user, _ = db.GetGuest()
+	}
+	return bandwidth.LimitResponse(c, c.Writer, user, account.GetAccount())
+}
+
 // TODO need optimize
 // when should be proxy?
 // 1. config.MustProxy()
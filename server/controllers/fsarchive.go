@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type ArchiveReq struct {
+	Dir      string   `json:"dir" form:"dir"`
+	Names    []string `json:"names" form:"names"`
+	Password string   `json:"password" form:"password"`
+}
+
+// FsArchive download the dir or the selected objs in the dir as a zip,
+// the zip is written while reading the files, so no temp file is needed
+func FsArchive(c *gin.Context) {
+	var req ArchiveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	req.Dir = stdpath.Join(user.BasePath, req.Dir)
+	meta, err := getNearestMeta(req.Dir)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !canAccess(user, meta, req.Dir, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect", 401)
+		return
+	}
+	// list the dir even if names are given, so that hidden objs can't be downloaded
+	objs, err := fs.List(archiveCtx(c, user, meta), req.Dir)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if len(req.Names) > 0 {
+		objs, err = selectObjs(objs, req.Names)
+		if err != nil {
+			common.ErrorResp(c, err, 404)
+			return
+		}
+	}
+	// collect the files before writing the response, so that the error can be responded
+	a := &archiver{c: c, user: user, password: req.Password}
+	for _, obj := range objs {
+		if err = a.add(req.Dir, "", obj); err != nil {
+			break
+		}
+	}
+	if errors.Is(err, errProxyNotAllowed) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	name := stdpath.Base(req.Dir)
+	if name == "/" {
+		name = "root"
+	}
+	name += ".zip"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, name, url.QueryEscape(name)))
+	c.Status(200)
+	if err = a.write(); err != nil {
+		// the response has been started, just abort it and the zip is broken
+		log.Errorf("failed archive %s: %+v", req.Dir, err)
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+func getNearestMeta(path string) (*model.Meta, error) {
+	meta, err := db.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// archiveCtx is the ctx that fs.List needs
+func archiveCtx(c *gin.Context, user *model.User, meta *model.Meta) context.Context {
+	ctx := context.WithValue(c.Request.Context(), "user", user)
+	return context.WithValue(ctx, "meta", meta)
+}
+
+func selectObjs(objs []model.Obj, names []string) ([]model.Obj, error) {
+	res := make([]model.Obj, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, obj := range objs {
+			if obj.GetName() == name {
+				res = append(res, obj)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Wrapf(errs.ObjectNotFound, "%s", name)
+		}
+	}
+	return res, nil
+}
+
+// errProxyNotAllowed is returned if a file to archive can't be proxied, like /p does
+var errProxyNotAllowed = errors.New("proxy not allowed")
+
+// archiveEntry is a file or an empty dir to write to the zip
+type archiveEntry struct {
+	path    string
+	name    string
+	obj     model.Obj
+	account driver.Driver
+}
+
+type archiver struct {
+	c        *gin.Context
+	user     *model.User
+	password string
+	entries  []archiveEntry
+	// out is the response limited by the account of the file being written
+	out switchWriter
+}
+
+type switchWriter struct {
+	w io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// add collect the obj in dir to write to the zip, prefix is the path of dir in the zip
+func (a *archiver) add(dir, prefix string, obj model.Obj) error {
+	path := stdpath.Join(dir, obj.GetName())
+	name := stdpath.Join(prefix, obj.GetName())
+	if !obj.IsDir() {
+		account, err := fs.GetAccount(path)
+		if err != nil {
+			return err
+		}
+		if !canProxy(account, obj.GetName()) {
+			return errors.Wrapf(errProxyNotAllowed, "%s", path)
+		}
+		a.entries = append(a.entries, archiveEntry{path: path, name: name, obj: obj, account: account})
+		return nil
+	}
+	meta, err := getNearestMeta(path)
+	if err != nil {
+		return err
+	}
+	// skip the dir that requires another password
+	if !canAccess(a.user, meta, path, a.password) {
+		return nil
+	}
+	objs, err := fs.List(archiveCtx(a.c, a.user, meta), path)
+	if err != nil {
+		return err
+	}
+	if len(objs) == 0 {
+		a.entries = append(a.entries, archiveEntry{path: path, name: name + "/", obj: obj})
+		return nil
+	}
+	for _, o := range objs {
+		if err := a.add(path, name, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write the collected entries to the response as a zip
+func (a *archiver) write() error {
+	a.out.w = a.c.Writer
+	w := zip.NewWriter(&a.out)
+	for _, e := range a.entries {
+		if e.obj.IsDir() {
+			if _, err := w.CreateHeader(&zip.FileHeader{Name: e.name, Modified: e.obj.ModTime()}); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		// the buffered data of the last file is written with its limiter
+		if err := w.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+		a.out.w = limitDown(a.c, e.account)
+		if err := a.addFile(w, e); err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(w.Close())
+}
+
+func (a *archiver) addFile(w *zip.Writer, e archiveEntry) error {
+	rc, err := fs.OpenRange(a.c.Request.Context(), e.path, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	fw, err := w.CreateHeader(&zip.FileHeader{
+		Name:     e.name,
+		Method:   zip.Store,
+		Modified: e.obj.ModTime(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.Copy(fw, rc)
+	return errors.Wrapf(err, "failed write %s", e.path)
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	db.Init(dB)
+	conf.Conf = conf.DefaultConfig()
+	gin.SetMode(gin.TestMode)
+}
+
+// noProxyDriver is a Local driver whose files can't be proxied
+type noProxyDriver struct {
+	local.Driver
+}
+
+func (d noProxyDriver) Config() driver.Config {
+	return driver.Config{Name: "NoProxy"}
+}
+
+func archive(t *testing.T, query string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", &model.User{Role: model.ADMIN, BasePath: "/"})
+	})
+	r.GET("/archive", FsArchive)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/archive?"+query, nil))
+	return w
+}
+
+func TestFsArchive(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"a.txt": "hello", "dir/b.txt": "archive", "dir/sub/c.txt": "deep"}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.Mkdir(filepath.Join(root, "empty"), 0755)
+	operations.RegisterDriver(driver.Config{Name: "NoProxy"}, func() driver.Driver {
+		return &noProxyDriver{}
+	})
+	for path, name := range map[string]string{"/archive": "Local", "/archive_no_proxy": "NoProxy"} {
+		err := operations.CreateAccount(context.Background(), model.Account{
+			Driver:      name,
+			VirtualPath: path,
+			Addition:    fmt.Sprintf(`{"root_folder":"%s"}`, filepath.ToSlash(root)),
+		})
+		if err != nil {
+			t.Fatalf("failed to create account: %+v", err)
+		}
+	}
+
+	w := archive(t, "dir=/archive")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %+v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %+v", f.Name, err)
+		}
+		data, _ := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		got[f.Name] = string(data)
+	}
+	for name, content := range files {
+		if got[name] != content {
+			t.Errorf("unexpected %s in zip: %q", name, got[name])
+		}
+	}
+	if _, ok := got["empty/"]; !ok {
+		t.Errorf("expected the empty dir in zip, got %v", got)
+	}
+
+	// the selected names only
+	w = archive(t, "dir=/archive/dir&names=sub")
+	zr, err = zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "sub/c.txt" {
+		t.Errorf("expected only sub/c.txt in zip, got %+v", err)
+	}
+
+	// the files can't be proxied are rejected before the zip is written
+	w = archive(t, "dir=/archive_no_proxy")
+	if w.Header().Get("Content-Type") == "application/zip" || !strings.Contains(w.Body.String(), `"code":403`) {
+		t.Errorf("expected proxy not allowed, got %s", w.Body.String())
+	}
+}
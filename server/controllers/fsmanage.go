@@ -125,6 +125,51 @@ func FsCopy(c *gin.Context) {
 	}
 }
 
+type DecompressReq struct {
+	SrcDir   string   `json:"src_dir"`
+	DstDir   string   `json:"dst_dir"`
+	Names    []string `json:"names"`
+	Password string   `json:"password"`
+	Conflict string   `json:"conflict"`
+}
+
+func FsDecompress(c *gin.Context) {
+	var req DecompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Names) == 0 {
+		common.ErrorStrResp(c, "Empty file names", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	req.SrcDir = stdpath.Join(user.BasePath, req.SrcDir)
+	req.DstDir = stdpath.Join(user.BasePath, req.DstDir)
+	if !user.CanWrite() {
+		meta, err := db.GetNearestMeta(req.DstDir)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		if !canWrite(meta, req.DstDir) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	for _, name := range req.Names {
+		err := fs.Decompress(c, stdpath.Join(req.SrcDir, name), req.DstDir, fs.DecompressArgs{
+			Password: req.Password,
+			Conflict: req.Conflict,
+		})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c, fmt.Sprintf("Added %d tasks", len(req.Names)))
+}
+
 type RenameReq struct {
 	Path string `json:"path"`
 	Name string `json:"name"`
@@ -217,7 +262,7 @@ func FsPut(c *gin.Context) {
 		WebPutAsTask: asTask,
 	}
 	if asTask {
-		err = fs.PutAsTask(dir, stream)
+		err = fs.PutAsTask(c, dir, stream)
 	} else {
 		err = fs.PutDirectly(c, dir, stream)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/operations"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -24,11 +25,12 @@ type ListReq struct {
 }
 
 type ObjResp struct {
-	Name     string    `json:"name"`
-	Size     int64     `json:"size"`
-	IsDir    bool      `json:"is_dir"`
-	Modified time.Time `json:"modified"`
-	Sign     string    `json:"sign"`
+	Name     string         `json:"name"`
+	Size     int64          `json:"size"`
+	IsDir    bool           `json:"is_dir"`
+	Modified time.Time      `json:"modified"`
+	Sign     string         `json:"sign"`
+	Hash     utils.HashInfo `json:"hash,omitempty"`
 }
 
 type FsListResp struct {
@@ -120,6 +122,7 @@ func toObjResp(objs []model.Obj) []ObjResp {
 			IsDir:    obj.IsDir(),
 			Modified: obj.ModTime(),
 			Sign:     common.Sign(obj),
+			Hash:     operations.HashOf(obj),
 		})
 	}
 	return resp
@@ -180,7 +183,15 @@ func FsGet(c *gin.Context) {
 					common.ErrorResp(c, err, 500)
 					return
 				}
-				rawURL = link.URL
+				if link.Data != nil {
+					_ = link.Data.Close()
+				}
+				if link.URL != "" {
+					rawURL = link.URL
+				} else {
+					// such as an entry in an archive, /d will proxy it
+					rawURL = fmt.Sprintf("%s/d%s?sign=%s", common.GetBaseUrl(c.Request), req.Path, sign.Sign(obj.GetName()))
+				}
 			}
 		}
 	}
@@ -191,6 +202,7 @@ func FsGet(c *gin.Context) {
 			IsDir:    obj.IsDir(),
 			Modified: obj.ModTime(),
 			Sign:     common.Sign(obj),
+			Hash:     operations.HashOf(obj),
 		},
 		RawURL: rawURL,
 	})
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/tus"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// the tus resumable upload protocol, see https://tus.io/protocols/resumable-upload.html
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+	tusOffsetType = "application/offset+octet-stream"
+)
+
+// tusError respond the error with the http status code, the tus clients rely on it
+func tusError(c *gin.Context, err error, code int) {
+	c.String(code, err.Error())
+	c.Abort()
+}
+
+// tusCheck check the protocol version of the client
+func tusCheck(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		tusError(c, errors.New("unsupported tus version"), http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// userCanWrite check if the user can write the path, by the permission of
+// the user or the meta of the path
+func userCanWrite(user *model.User, path string) (bool, error) {
+	if user.CanWrite() {
+		return true, nil
+	}
+	meta, err := db.GetNearestMeta(path)
+	if err != nil {
+		return false, err
+	}
+	return canWrite(meta, path), nil
+}
+
+// getTusUpload get the upload of the current user by the id in the url
+func getTusUpload(c *gin.Context) (*tus.Upload, bool) {
+	u, err := tus.Get(c.Param("id"))
+	if err == nil && u.Creator != c.MustGet("user").(*model.User).Username {
+		err = errors.WithStack(tus.ErrNotFound)
+	}
+	if err != nil {
+		if errors.Is(err, tus.ErrNotFound) {
+			tusError(c, err, http.StatusNotFound)
+		} else {
+			tusError(c, err, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return u, true
+}
+
+// parseTusMetadata parse the Upload-Metadata header, which is comma separated
+// pairs of the key and the base64 encoded value, the value may be omitted
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, " ")
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid metadata of %s", key)
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata, nil
+}
+
+func encodeTusMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func tusUploadHeader(c *gin.Context, u *tus.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+}
+
+// TusOptions respond the capabilities of the server
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Status(http.StatusNoContent)
+}
+
+// TusCreate create an upload, the path is given by the File-Path header or the path metadata,
+// and it's put as a task if the As-Task header or the as_task metadata is true
+func TusCreate(c *gin.Context) {
+	if !tusCheck(c) {
+		return
+	}
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		tusError(c, errors.New("deferred length is not supported"), http.StatusBadRequest)
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		tusError(c, errors.New("invalid Upload-Length"), http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		tusError(c, err, http.StatusBadRequest)
+		return
+	}
+	path := c.GetHeader("File-Path")
+	if path == "" {
+		path = metadata["path"]
+	}
+	if path == "" {
+		tusError(c, errors.New("the path of the file is required"), http.StatusBadRequest)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	path = stdpath.Join(user.BasePath, path)
+	ok, err := userCanWrite(user, path)
+	if err != nil {
+		tusError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		tusError(c, errs.PermissionDenied, http.StatusForbidden)
+		return
+	}
+	u := &tus.Upload{
+		Path:     path,
+		Size:     size,
+		Mimetype: metadata["filetype"],
+		AsTask:   c.GetHeader("As-Task") == "true" || metadata["as_task"] == "true",
+		Metadata: metadata,
+		Creator:  user.Username,
+	}
+	if err := tus.Create(u); err != nil {
+		tusError(c, err, http.StatusInternalServerError)
+		return
+	}
+	// the empty file is finished once created
+	if u.Done() {
+		if err := completeTusUpload(c, u.ID); err != nil {
+			tusError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	c.Header("Location", common.GetBaseUrl(c.Request)+"/api/fs/tus/"+u.ID)
+	tusUploadHeader(c, u)
+	c.Status(http.StatusCreated)
+}
+
+// TusHead respond the received size of the upload
+func TusHead(c *gin.Context) {
+	if !tusCheck(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Length", strconv.FormatInt(u.Size, 10))
+	if len(u.Metadata) > 0 {
+		c.Header("Upload-Metadata", encodeTusMetadata(u.Metadata))
+	}
+	tusUploadHeader(c, u)
+	c.Status(http.StatusOK)
+}
+
+// TusPatch receive the bytes from Upload-Offset, the file is put once all the bytes received
+func TusPatch(c *gin.Context) {
+	if !tusCheck(c) {
+		return
+	}
+	if c.ContentType() != tusOffsetType {
+		tusError(c, errors.Errorf("the Content-Type should be %s", tusOffsetType), http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		tusError(c, errors.New("invalid Upload-Offset"), http.StatusBadRequest)
+		return
+	}
+	if _, ok := getTusUpload(c); !ok {
+		return
+	}
+	u, err := tus.Write(c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, tus.ErrNotFound):
+			tusError(c, err, http.StatusNotFound)
+		case errors.Is(err, tus.ErrOffsetMismatch):
+			tusError(c, err, http.StatusConflict)
+		case errors.Is(err, tus.ErrLocked):
+			tusError(c, err, http.StatusLocked)
+		default:
+			tusError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	if u.Done() {
+		if err := completeTusUpload(c, u.ID); err != nil {
+			tusError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	tusUploadHeader(c, u)
+	c.Status(http.StatusNoContent)
+}
+
+// TusTerminate delete the upload with the received bytes
+func TusTerminate(c *gin.Context) {
+	if !tusCheck(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if err := tus.Remove(u.ID); err != nil {
+		tusError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// completeTusUpload put the finished upload, the permission is checked again
+// because it may be changed during the upload
+func completeTusUpload(c *gin.Context, id string) error {
+	return tus.Complete(id, func(u *tus.Upload, f *os.File) error {
+		user := c.MustGet("user").(*model.User)
+		ok, err := userCanWrite(user, u.Path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.WithStack(errs.PermissionDenied)
+		}
+		dir, name := stdpath.Split(u.Path)
+		stream := &model.FileStream{
+			Obj: model.Object{
+				Name:     name,
+				Size:     u.Size,
+				Modified: time.Now(),
+			},
+			ReadCloser: f,
+			Mimetype:   u.Mimetype,
+		}
+		if u.AsTask {
+			// the file is already stored, the task uploads from it
+			return fs.PutAsTask(c, dir, stream)
+		}
+		return fs.PutDirectly(c, dir, stream)
+	})
+}
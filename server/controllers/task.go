@@ -16,17 +16,29 @@ type TaskInfo struct {
 	Status   string `json:"status"`
 	Progress int    `json:"progress"`
 	Error    string `json:"error"`
+	Priority int    `json:"priority"`
+	// Attempts is the history of the failed attempts
+	Attempts []task.Attempt `json:"attempts,omitempty"`
+	// Stats is the aggregate of the children of a group task
+	Stats *task.Stats `json:"stats,omitempty"`
 }
 
-func getTaskInfoUint(task *task.Task[uint64]) TaskInfo {
-	return TaskInfo{
-		ID:       strconv.FormatUint(task.ID, 10),
-		Name:     task.Name,
-		State:    task.GetState(),
-		Status:   task.GetStatus(),
-		Progress: task.GetProgress(),
-		Error:    task.Error.Error(),
+func getTaskInfoUint(t *task.Task[uint64]) TaskInfo {
+	info := TaskInfo{
+		ID:       strconv.FormatUint(t.ID, 10),
+		Name:     t.Name,
+		State:    t.GetState(),
+		Status:   t.GetStatus(),
+		Progress: t.GetProgress(),
+		Error:    t.GetErrMsg(),
+		Priority: t.GetPriority(),
+		Attempts: t.GetAttempts(),
+	}
+	if t.Group {
+		stats := t.Stats()
+		info.Stats = &stats
 	}
+	return info
 }
 
 func getTaskInfoStr(task *task.Task[string]) TaskInfo {
@@ -37,13 +49,19 @@ func getTaskInfoStr(task *task.Task[string]) TaskInfo {
 		Status:   task.GetStatus(),
 		Progress: task.GetProgress(),
 		Error:    task.GetErrMsg(),
+		Priority: task.GetPriority(),
+		Attempts: task.GetAttempts(),
 	}
 }
 
+// getTaskInfosUint return the infos of the tasks, the children are skipped
+// because they are shown in their parents
 func getTaskInfosUint(tasks []*task.Task[uint64]) []TaskInfo {
 	var infos []TaskInfo
 	for _, t := range tasks {
-		infos = append(infos, getTaskInfoUint(t))
+		if t.GetParent() == nil {
+			infos = append(infos, getTaskInfoUint(t))
+		}
 	}
 	return infos
 }
@@ -56,6 +74,53 @@ func getTaskInfosStr(tasks []*task.Task[string]) []TaskInfo {
 	return infos
 }
 
+// taskOpStr call the op of task manager with the tid in query
+func taskOpStr(c *gin.Context, op func(tid string) error) {
+	if err := op(c.Query("tid")); err != nil {
+		common.ErrorResp(c, err, 500)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+// taskOpUint is the same as taskOpStr but the tid is uint64
+func taskOpUint(c *gin.Context, op func(tid uint64) error) {
+	tid, err := strconv.ParseUint(c.Query("tid"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op(tid); err != nil {
+		common.ErrorResp(c, err, 500)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+// taskPriorityStr set the priority in query of the task
+func taskPriorityStr(c *gin.Context, set func(tid string, priority int) error) {
+	priority, err := strconv.Atoi(c.Query("priority"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	taskOpStr(c, func(tid string) error {
+		return set(tid, priority)
+	})
+}
+
+// taskPriorityUint is the same as taskPriorityStr but the tid is uint64
+func taskPriorityUint(c *gin.Context, set func(tid uint64, priority int) error) {
+	priority, err := strconv.Atoi(c.Query("priority"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	taskOpUint(c, func(tid uint64) error {
+		return set(tid, priority)
+	})
+}
+
 func UndoneDownTask(c *gin.Context) {
 	common.SuccessResp(c, getTaskInfosStr(aria2.DownTaskManager.ListUndone()))
 }
@@ -73,6 +138,22 @@ func CancelDownTask(c *gin.Context) {
 	}
 }
 
+func PauseDownTask(c *gin.Context) {
+	taskOpStr(c, aria2.DownTaskManager.Pause)
+}
+
+func ResumeDownTask(c *gin.Context) {
+	taskOpStr(c, aria2.DownTaskManager.Resume)
+}
+
+func RetryDownTask(c *gin.Context) {
+	taskOpStr(c, aria2.DownTaskManager.Retry)
+}
+
+func PriorityDownTask(c *gin.Context) {
+	taskPriorityStr(c, aria2.DownTaskManager.SetPriority)
+}
+
 func UndoneTransferTask(c *gin.Context) {
 	common.SuccessResp(c, getTaskInfosUint(aria2.TransferTaskManager.ListUndone()))
 }
@@ -95,6 +176,22 @@ func CancelTransferTask(c *gin.Context) {
 	}
 }
 
+func PauseTransferTask(c *gin.Context) {
+	taskOpUint(c, aria2.TransferTaskManager.Pause)
+}
+
+func ResumeTransferTask(c *gin.Context) {
+	taskOpUint(c, aria2.TransferTaskManager.Resume)
+}
+
+func RetryTransferTask(c *gin.Context) {
+	taskOpUint(c, aria2.TransferTaskManager.Retry)
+}
+
+func PriorityTransferTask(c *gin.Context) {
+	taskPriorityUint(c, aria2.TransferTaskManager.SetPriority)
+}
+
 func UndoneUploadTask(c *gin.Context) {
 	common.SuccessResp(c, getTaskInfosUint(fs.UploadTaskManager.ListUndone()))
 }
@@ -117,6 +214,22 @@ func CancelUploadTask(c *gin.Context) {
 	}
 }
 
+func PauseUploadTask(c *gin.Context) {
+	taskOpUint(c, fs.UploadTaskManager.Pause)
+}
+
+func ResumeUploadTask(c *gin.Context) {
+	taskOpUint(c, fs.UploadTaskManager.Resume)
+}
+
+func RetryUploadTask(c *gin.Context) {
+	taskOpUint(c, fs.UploadTaskManager.Retry)
+}
+
+func PriorityUploadTask(c *gin.Context) {
+	taskPriorityUint(c, fs.UploadTaskManager.SetPriority)
+}
+
 func UndoneCopyTask(c *gin.Context) {
 	common.SuccessResp(c, getTaskInfosUint(fs.CopyTaskManager.ListUndone()))
 }
@@ -138,3 +251,76 @@ func CancelCopyTask(c *gin.Context) {
 		common.SuccessResp(c)
 	}
 }
+
+func ChildrenCopyTask(c *gin.Context) {
+	tid, err := strconv.ParseUint(c.Query("tid"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t, ok := fs.CopyTaskManager.Get(tid)
+	if !ok {
+		common.ErrorResp(c, task.ErrTaskNotFound, 404)
+		return
+	}
+	children := t.GetChildren()
+	infos := make([]TaskInfo, len(children))
+	for i, child := range children {
+		infos[i] = getTaskInfoUint(child)
+	}
+	common.SuccessResp(c, infos)
+}
+
+func PauseCopyTask(c *gin.Context) {
+	taskOpUint(c, fs.CopyTaskManager.Pause)
+}
+
+func ResumeCopyTask(c *gin.Context) {
+	taskOpUint(c, fs.CopyTaskManager.Resume)
+}
+
+func RetryCopyTask(c *gin.Context) {
+	taskOpUint(c, fs.CopyTaskManager.Retry)
+}
+
+func PriorityCopyTask(c *gin.Context) {
+	taskPriorityUint(c, fs.CopyTaskManager.SetPriority)
+}
+
+func UndoneDecompressTask(c *gin.Context) {
+	common.SuccessResp(c, getTaskInfosUint(fs.DecompressTaskManager.ListUndone()))
+}
+
+func DoneDecompressTask(c *gin.Context) {
+	common.SuccessResp(c, getTaskInfosUint(fs.DecompressTaskManager.ListDone()))
+}
+
+func CancelDecompressTask(c *gin.Context) {
+	id := c.Query("tid")
+	tid, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := fs.DecompressTaskManager.Cancel(tid); err != nil {
+		common.ErrorResp(c, err, 500)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func PauseDecompressTask(c *gin.Context) {
+	taskOpUint(c, fs.DecompressTaskManager.Pause)
+}
+
+func ResumeDecompressTask(c *gin.Context) {
+	taskOpUint(c, fs.DecompressTaskManager.Resume)
+}
+
+func RetryDecompressTask(c *gin.Context) {
+	taskOpUint(c, fs.DecompressTaskManager.Retry)
+}
+
+func PriorityDecompressTask(c *gin.Context) {
+	taskPriorityUint(c, fs.DecompressTaskManager.SetPriority)
+}
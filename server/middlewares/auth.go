@@ -51,6 +51,15 @@ func Auth(c *gin.Context) {
 	c.Next()
 }
 
+// QueryToken take the token from the query if the Authorization header is empty,
+// since the browsers can't set headers for EventSource and WebSocket
+func QueryToken(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		c.Request.Header.Set("Authorization", c.Query("token"))
+	}
+	c.Next()
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	if !user.IsAdmin() {
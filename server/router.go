@@ -42,6 +42,7 @@ func Init(r *gin.Engine) {
 	account.POST("/create", controllers.CreateAccount)
 	account.POST("/update", controllers.UpdateAccount)
 	account.POST("/delete", controllers.DeleteAccount)
+	account.POST("/reload", controllers.ReloadAccount)
 
 	driver := admin.Group("/driver")
 	driver.GET("/list", controllers.ListDriverItems)
@@ -55,25 +56,62 @@ func Init(r *gin.Engine) {
 	setting.POST("/delete", controllers.DeleteSetting)
 	setting.POST("/reset_token", controllers.ResetToken)
 	setting.POST("/set_aria2", controllers.SetAria2)
+	setting.POST("/set_qbit", controllers.SetQbittorrent)
+	setting.POST("/set_transmission", controllers.SetTransmission)
 
 	task := admin.Group("/task")
 	task.GET("/down/undone", controllers.UndoneDownTask)
 	task.GET("/down/done", controllers.DoneDownTask)
 	task.POST("/down/cancel", controllers.CancelDownTask)
+	task.POST("/down/pause", controllers.PauseDownTask)
+	task.POST("/down/resume", controllers.ResumeDownTask)
+	task.POST("/down/retry", controllers.RetryDownTask)
+	task.POST("/down/priority", controllers.PriorityDownTask)
 	task.GET("/transfer/undone", controllers.UndoneTransferTask)
 	task.GET("/transfer/done", controllers.DoneTransferTask)
 	task.POST("/transfer/cancel", controllers.CancelTransferTask)
+	task.POST("/transfer/pause", controllers.PauseTransferTask)
+	task.POST("/transfer/resume", controllers.ResumeTransferTask)
+	task.POST("/transfer/retry", controllers.RetryTransferTask)
+	task.POST("/transfer/priority", controllers.PriorityTransferTask)
 	task.GET("/upload/undone", controllers.UndoneUploadTask)
 	task.GET("/upload/done", controllers.DoneUploadTask)
 	task.POST("/upload/cancel", controllers.CancelUploadTask)
+	task.POST("/upload/pause", controllers.PauseUploadTask)
+	task.POST("/upload/resume", controllers.ResumeUploadTask)
+	task.POST("/upload/retry", controllers.RetryUploadTask)
+	task.POST("/upload/priority", controllers.PriorityUploadTask)
 	task.GET("/copy/undone", controllers.UndoneCopyTask)
 	task.GET("/copy/done", controllers.DoneCopyTask)
+	task.GET("/copy/children", controllers.ChildrenCopyTask)
 	task.POST("/copy/cancel", controllers.CancelCopyTask)
+	task.POST("/copy/pause", controllers.PauseCopyTask)
+	task.POST("/copy/resume", controllers.ResumeCopyTask)
+	task.POST("/copy/retry", controllers.RetryCopyTask)
+	task.POST("/copy/priority", controllers.PriorityCopyTask)
+	task.GET("/decompress/undone", controllers.UndoneDecompressTask)
+	task.GET("/decompress/done", controllers.DoneDecompressTask)
+	task.POST("/decompress/cancel", controllers.CancelDecompressTask)
+	task.POST("/decompress/pause", controllers.PauseDecompressTask)
+	task.POST("/decompress/resume", controllers.ResumeDecompressTask)
+	task.POST("/decompress/retry", controllers.RetryDecompressTask)
+	task.POST("/decompress/priority", controllers.PriorityDecompressTask)
+
+	sync := admin.Group("/sync")
+	sync.GET("/list", controllers.ListSyncJobs)
+	sync.POST("/create", controllers.CreateSyncJob)
+	sync.POST("/update", controllers.UpdateSyncJob)
+	sync.POST("/delete", controllers.DeleteSyncJob)
+	sync.POST("/run", controllers.RunSyncJob)
 
 	ms := admin.Group("/message")
 	ms.GET("/get", message.PostInstance.GetHandle)
 	ms.POST("/send", message.PostInstance.SendHandle)
 
+	stream := r.Group("/api/stream", middlewares.QueryToken, middlewares.Auth)
+	stream.GET("/sse", message.HubInstance.SSEHandle)
+	stream.GET("/ws", message.HubInstance.WsHandle)
+
 	// guest can
 	public := api.Group("/public")
 	public.GET("/settings", controllers.PublicSettings)
@@ -86,8 +124,16 @@ func Init(r *gin.Engine) {
 	fs.POST("/rename", controllers.FsRename)
 	fs.POST("/move", controllers.FsMove)
 	fs.POST("/copy", controllers.FsCopy)
+	fs.POST("/decompress", controllers.FsDecompress)
 	fs.POST("/remove", controllers.FsRemove)
 	fs.POST("/put", controllers.FsPut)
+	tus := fs.Group("/tus")
+	tus.OPTIONS("", controllers.TusOptions)
+	tus.POST("", controllers.TusCreate)
+	tus.HEAD("/:id", controllers.TusHead)
+	tus.PATCH("/:id", controllers.TusPatch)
+	tus.DELETE("/:id", controllers.TusTerminate)
+	fs.Any("/archive", controllers.FsArchive)
 	fs.POST("/link", middlewares.AuthAdmin, controllers.Link)
 	fs.POST("/add_aria2", controllers.AddAria2)
 }
@@ -95,6 +141,9 @@ func Init(r *gin.Engine) {
 func Cors(r *gin.Engine) {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "range", "File-Path", "As-Task")
+	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "range", "File-Path", "As-Task",
+		"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "Upload-Defer-Length")
+	config.ExposeHeaders = append(config.ExposeHeaders, "Location", "Tus-Resumable", "Tus-Version",
+		"Tus-Extension", "Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires")
 	r.Use(cors.New(config))
 }
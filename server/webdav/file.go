@@ -7,9 +7,11 @@ package webdav
 import (
 	"context"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
 )
@@ -55,13 +57,32 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 //
 // See section 9.8.5 for when various HTTP status codes apply.
 func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int, err error) {
-	_, err = fs.Copy(ctx, src, dst)
+	created := false
+	if _, err := fs.Get(ctx, dst); err != nil {
+		if !errs.IsObjectNotFound(err) {
+			return http.StatusForbidden, err
+		}
+		created = true
+	} else {
+		if !overwrite {
+			return http.StatusPreconditionFailed, os.ErrExist
+		}
+		if err := fs.Remove(ctx, dst); err != nil {
+			return http.StatusForbidden, err
+		}
+	}
+	dstDir := path.Dir(dst)
+	// copy to the dst name directly, the copy between two accounts
+	// is done by task which puts the file under the dst name too
+	_, err = fs.CopyAs(ctx, src, dstDir, path.Base(dst))
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
-	fs.ClearCache(path.Dir(dst))
-	// TODO if there are no files copy, should return 204
-	return http.StatusCreated, nil
+	fs.ClearCache(dstDir)
+	if created {
+		return http.StatusCreated, nil
+	}
+	return http.StatusNoContent, nil
 }
 
 // walkFS traverses filesystem fs starting at name up to depth levels.
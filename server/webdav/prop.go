@@ -14,7 +14,9 @@ import (
 	"mime"
 	"net/http"
 	"path"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // Proppatch describes a property update instruction as defined in RFC 4918.
@@ -150,6 +152,12 @@ var liveProps = map[xml.Name]struct {
 		dir: false,
 	},
 
+	// the checksums of ownCloud, which is supported by many clients
+	{Space: "http://owncloud.org/ns", Local: "checksums"}: {
+		findFn: findChecksums,
+		dir:    false,
+	},
+
 	// TODO: The lockdiscovery property requires LockSystem to list the
 	// active locks on a resource.
 	{Space: "DAV:", Local: "lockdiscovery"}: {},
@@ -465,6 +473,20 @@ func findETag(ctx context.Context, ls LockSystem, name string, fi model.Obj) (st
 	return fmt.Sprintf(`"%x%x"`, fi.ModTime().UnixNano(), fi.GetSize()), nil
 }
 
+// findChecksums list the known hashes of the file in the form of "MD5:hex SHA1:hex"
+func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	h, ok := fi.(model.Hash)
+	if !ok || len(h.GetHash()) == 0 {
+		return "", nil
+	}
+	var sums []string
+	for t, sum := range h.GetHash() {
+		sums = append(sums, strings.ToUpper(string(t))+":"+sum)
+	}
+	sort.Strings(sums)
+	return "<checksum>" + strings.Join(sums, " ") + "</checksum>", nil
+}
+
 func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
 	return `` +
 		`<D:lockentry xmlns:D="DAV:">` +
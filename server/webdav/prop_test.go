@@ -0,0 +1,31 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestPropsChecksums(t *testing.T) {
+	checksums := xml.Name{Space: "http://owncloud.org/ns", Local: "checksums"}
+	file := &model.Object{Name: "a.txt", Hash: utils.HashInfo{utils.SHA1: "a9993e36", utils.MD5: "900150983cd2"}}
+	pstats, err := props(context.Background(), nil, file, []xml.Name{checksums})
+	if err != nil {
+		t.Fatalf("failed props: %+v", err)
+	}
+	w := httptest.NewRecorder()
+	mw := multistatusWriter{w: w}
+	if err := mw.write(makePropstatResponse("/a.txt", pstats)); err != nil {
+		t.Fatal(err)
+	}
+	_ = mw.close()
+	expected := `<checksums xmlns="http://owncloud.org/ns"><checksum>MD5:900150983cd2 SHA1:a9993e36</checksum></checksums>`
+	if !strings.Contains(w.Body.String(), expected) {
+		t.Errorf("expected %s in %s", expected, w.Body.String())
+	}
+}
@@ -8,6 +8,7 @@ package webdav // import "golang.org/x/net/webdav"
 import (
 	"errors"
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -222,7 +223,7 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
-		err = common.Proxy(w, r, link, fi)
+		err = common.Proxy(bandwidth.LimitResponse(ctx, w, user, account.GetAccount()), r, link, fi)
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
@@ -230,10 +231,18 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		u := fmt.Sprintf("%s/p%s?sign=%s", common.GetBaseUrl(r), reqPath, sign.Sign(path.Base(reqPath)))
 		http.Redirect(w, r, u, 302)
 	} else {
-		link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{IP: utils.ClientIP(r)})
+		link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{IP: utils.ClientIP(r), Header: r.Header})
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
+		// such as an entry in an archive, which can only be proxied
+		if link.URL == "" {
+			err = common.Proxy(bandwidth.LimitResponse(ctx, w, user, account.GetAccount()), r, link, fi)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			return 0, nil
+		}
 		http.Redirect(w, r, link.URL, 302)
 	}
 	return 0, nil